@@ -0,0 +1,324 @@
+// Package bot answers commands sent to the Telegram bot by authorised chats.
+package bot
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go-stock/calendar"
+	"go-stock/chatlist"
+	"go-stock/config"
+	"go-stock/marketfall"
+	"go-stock/notify"
+	"go-stock/render"
+	"go-stock/replay"
+	"go-stock/stock"
+	"go-stock/telegram"
+)
+
+// pollTimeout is how long each getUpdates call waits for new messages.
+// It must stay below the HTTP client timeout.
+const pollTimeout = 25 * time.Second
+
+// retryDelay is the pause after a failed poll
+const retryDelay = 5 * time.Second
+
+// Commands are answered by a fixed set of workers so one slow /analyze does
+// not hold up other chats. Each chat always goes to the same worker, so its
+// own commands are answered in the order they were sent.
+const (
+	workers     = 4
+	workerQueue = 16 // Messages waiting per worker before polling pauses
+)
+
+// command is a bot command and the handler that builds its reply
+type command struct {
+	name        string
+	usage       string
+	description string
+	run         func(chatID string, args []string) notify.Message
+}
+
+// commands in the order /help lists them
+var commands []command
+
+func init() {
+	// Registered in init because /help lists the commands itself
+	commands = []command{
+		{"quote", "/quote SYMBOL", "Latest price and technical indicators", quote},
+		{"analyze", "/analyze SYMBOL", "Full report card with AI insights", analyze},
+		{"marketfall", "/marketfall", "Run the market fall check now", marketFall},
+		{"add", "/add SYMBOL...", "Add symbols to this chat's daily report", add},
+		{"remove", "/remove SYMBOL...", "Remove symbols from this chat's daily report", remove},
+		{"list", "/list", "Show this chat's watchlist", list},
+		{"help", "/help", "Show this message", help},
+	}
+}
+
+// Bot polls Telegram and replies to commands
+type Bot struct {
+	client     *telegram.Client
+	token      string
+	parseMode  string
+	authorized map[string]bool
+}
+
+// Run long-polls Telegram for commands and answers them until SIGINT or
+// SIGTERM. Only chats in TELEGRAM_CHAT_IDS may use the bot.
+func Run() error {
+	cfg := config.GetConfig()
+	if cfg.TelegramBotToken == "" {
+		return fmt.Errorf("TELEGRAM_BOT_TOKEN not set")
+	}
+	if len(cfg.TelegramChatIDs) == 0 {
+		return fmt.Errorf("TELEGRAM_CHAT_IDS not set, no chat would be authorised")
+	}
+
+	b := &Bot{
+		client:     telegram.NewClient(cfg.TelegramBotToken),
+		token:      cfg.TelegramBotToken,
+		parseMode:  cfg.TelegramParseMode,
+		authorized: make(map[string]bool),
+	}
+	for _, id := range cfg.TelegramChatIDs {
+		b.authorized[id] = true
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	queues := make([]chan *telegram.Message, workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan *telegram.Message, workerQueue)
+		wg.Add(1)
+		go func(queue <-chan *telegram.Message) {
+			defer wg.Done()
+			for msg := range queue {
+				b.handle(msg)
+			}
+		}(queues[i])
+	}
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait() // Finish the commands already received
+		fmt.Println("Bot stopped")
+	}()
+
+	fmt.Printf("Bot listening for commands from %d chat(s)\n", len(b.authorized))
+	offset := 0
+	for {
+		updates, err := b.client.GetUpdates(ctx, offset, pollTimeout)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			fmt.Printf("Error polling Telegram: %v\n", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			if update.Message != nil {
+				queues[worker(update.Message.Chat.ID)] <- update.Message
+			}
+		}
+	}
+}
+
+// worker picks the worker that answers a chat
+func worker(chatID int64) int {
+	if chatID < 0 {
+		chatID = -chatID // Group chat IDs are negative
+	}
+	return int(chatID % workers)
+}
+
+// handle answers a single message if it is a command from an authorised chat
+func (b *Bot) handle(msg *telegram.Message) {
+	name, args, ok := parseCommand(msg.Text)
+	if !ok {
+		return
+	}
+
+	chatID := msg.ChatID()
+	if !b.authorized[chatID] {
+		fmt.Printf("Ignoring /%s from unauthorised chat %s\n", name, chatID)
+		b.reply(chatID, notify.Message{Title: render.Text("This chat is not authorised to use this bot.")})
+		return
+	}
+
+	if name == "start" {
+		name = "help" // Sent by Telegram when a chat first opens the bot
+	}
+
+	fmt.Printf("Chat %s: /%s %s\n", chatID, name, strings.Join(args, " "))
+	for _, c := range commands {
+		if c.name == name {
+			b.reply(chatID, c.run(chatID, args))
+			return
+		}
+	}
+	b.reply(chatID, notify.Message{Title: render.Text("Unknown command /" + name + ". Send /help for the list of commands.")})
+}
+
+// reply sends a message to one chat, splitting and escaping it like the reports
+func (b *Bot) reply(chatID string, msg notify.Message) {
+	delivery := notify.NewTelegram("bot", b.token, b.parseMode, []string{chatID}).Send(msg)
+	if err := delivery.Err(); err != nil {
+		fmt.Printf("Error replying: %v\n", err)
+	}
+}
+
+// parseCommand splits "/quote@MyBot RELIANCE.NS" into "quote" and its arguments
+func parseCommand(text string) (string, []string, bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", nil, false
+	}
+	name := strings.TrimPrefix(fields[0], "/")
+	if at := strings.Index(name, "@"); at >= 0 {
+		name = name[:at]
+	}
+	return strings.ToLower(name), fields[1:], name != ""
+}
+
+// normalizeSymbol upper-cases a symbol and defaults it to NSE, so "tcs" becomes "TCS.NS".
+// Indices (^NSEI) and symbols with an exchange suffix are left as given.
+func normalizeSymbol(symbol string) string {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if strings.HasPrefix(symbol, "^") || strings.Contains(symbol, ".") {
+		return symbol
+	}
+	return symbol + ".NS"
+}
+
+// usageMessage shows how a command is used
+func usageMessage(usage string) notify.Message {
+	return notify.Message{Title: render.Document{render.Line{render.Plain("Usage: "), render.Code(usage)}}}
+}
+
+// symbolArg returns the single symbol argument of a command, or a usage reply
+func symbolArg(args []string, usage string) (string, *notify.Message) {
+	if len(args) != 1 {
+		msg := usageMessage(usage)
+		return "", &msg
+	}
+	return normalizeSymbol(args[0]), nil
+}
+
+func quote(chatID string, args []string) notify.Message {
+	symbol, usage := symbolArg(args, "/quote SYMBOL")
+	if usage != nil {
+		return *usage
+	}
+	card, err := stock.Quote(symbol)
+	if err != nil {
+		fmt.Printf("Error quoting %s: %v\n", symbol, err)
+		return notify.Message{Title: render.Text("Could not fetch " + symbol + ". Check the symbol and try again.")}
+	}
+	return notify.Message{Sections: []render.Document{card}}
+}
+
+func analyze(chatID string, args []string) notify.Message {
+	symbol, usage := symbolArg(args, "/analyze SYMBOL")
+	if usage != nil {
+		return *usage
+	}
+	msg, err := stock.Analyze(symbol)
+	if err != nil {
+		fmt.Printf("Error analysing %s: %v\n", symbol, err)
+		return notify.Message{Title: render.Text("Could not analyse " + symbol + ". Check the symbol and try again.")}
+	}
+	return msg
+}
+
+func marketFall(chatID string, args []string) notify.Message {
+	return marketfall.Report(calendar.Default().StatusAt(replay.Now()), nil)
+}
+
+func help(chatID string, args []string) notify.Message {
+	var lines render.Document
+	for _, c := range commands {
+		lines = append(lines, render.Line{render.Code(c.usage), render.Plain(" - " + c.description)})
+	}
+	lines = append(lines, render.Paragraph("Symbols default to NSE, so /quote TCS is the same as /quote TCS.NS."))
+	return notify.Message{Title: render.Document{render.Line{render.Bold("Available commands")}}, Sections: []render.Document{lines}}
+}
+
+func add(chatID string, args []string) notify.Message {
+	if len(args) == 0 {
+		return usageMessage("/add SYMBOL...")
+	}
+
+	var valid []string
+	var problems []string
+	for _, arg := range args {
+		symbol := normalizeSymbol(arg)
+		if err := stock.CheckSymbol(symbol); err != nil {
+			fmt.Printf("Error checking %s: %v\n", symbol, err)
+			problems = append(problems, "Unknown symbol "+symbol)
+			continue
+		}
+		valid = append(valid, symbol)
+	}
+
+	added, err := chatlist.Default().Add(chatID, valid...)
+	if err != nil {
+		fmt.Printf("Error saving watchlist for chat %s: %v\n", chatID, err)
+		return notify.Message{Title: render.Text("Could not save your watchlist, please try again later.")}
+	}
+	if len(added) < len(valid) {
+		problems = append(problems, fmt.Sprintf("Skipped symbols already listed or over the limit of %d", chatlist.MaxSymbols))
+	}
+	if len(added) > 0 {
+		problems = append([]string{"Added " + strings.Join(added, ", ")}, problems...)
+	}
+	return notify.Message{Title: render.Text(strings.Join(problems, "\n"))}
+}
+
+func remove(chatID string, args []string) notify.Message {
+	if len(args) == 0 {
+		return usageMessage("/remove SYMBOL...")
+	}
+
+	symbols := make([]string, len(args))
+	for i, arg := range args {
+		symbols[i] = normalizeSymbol(arg)
+	}
+	removed, err := chatlist.Default().Remove(chatID, symbols...)
+	if err != nil {
+		fmt.Printf("Error saving watchlist for chat %s: %v\n", chatID, err)
+		return notify.Message{Title: render.Text("Could not save your watchlist, please try again later.")}
+	}
+	if len(removed) == 0 {
+		return notify.Message{Title: render.Text("None of those symbols are on your watchlist.")}
+	}
+	return notify.Message{Title: render.Text("Removed " + strings.Join(removed, ", "))}
+}
+
+func list(chatID string, args []string) notify.Message {
+	symbols, err := chatlist.Default().List(chatID)
+	if err != nil {
+		fmt.Printf("Error loading watchlist for chat %s: %v\n", chatID, err)
+		return notify.Message{Title: render.Text("Could not load your watchlist, please try again later.")}
+	}
+	if len(symbols) == 0 {
+		return notify.Message{Title: render.Text("Your watchlist is empty. Add symbols with /add SYMBOL.")}
+	}
+	return notify.Message{
+		Title:    render.Document{render.Line{render.Bold("Your Watchlist")}},
+		Sections: []render.Document{render.Text(strings.Join(symbols, "\n"))},
+	}
+}
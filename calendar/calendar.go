@@ -0,0 +1,200 @@
+// Package calendar knows when the NSE and BSE equity markets are open.
+package calendar
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	_ "time/tzdata" // Containers often ship without zoneinfo
+
+	"go-stock/config"
+)
+
+//go:embed nse_holidays.txt
+var bundledHolidays string
+
+// Phase is a part of the trading day
+type Phase string
+
+const (
+	PhaseClosed  Phase = "closed"   // Outside all sessions, or a holiday/weekend
+	PhasePreOpen Phase = "pre-open" // Pre-open call auction
+	PhaseRegular Phase = "regular"  // Continuous trading
+	PhaseClosing Phase = "closing"  // Post-close session at the closing price
+)
+
+// Session is a window within a trading day, in exchange local time
+type Session struct {
+	Phase Phase
+	Start time.Duration // Offset from midnight
+	End   time.Duration
+}
+
+// Equity market timings shared by NSE and BSE
+var (
+	PreOpenSession = Session{Phase: PhasePreOpen, Start: 9 * time.Hour, End: 9*time.Hour + 15*time.Minute}
+	RegularSession = Session{Phase: PhaseRegular, Start: 9*time.Hour + 15*time.Minute, End: 15*time.Hour + 30*time.Minute}
+	ClosingSession = Session{Phase: PhaseClosing, Start: 15*time.Hour + 40*time.Minute, End: 16 * time.Hour}
+
+	Sessions = []Session{PreOpenSession, RegularSession, ClosingSession}
+)
+
+// Calendar answers trading-day and session questions for NSE/BSE
+type Calendar struct {
+	location *time.Location
+	holidays map[string]string // YYYY-MM-DD -> description
+}
+
+// New returns a calendar using the holiday file at path, or the bundled
+// list when path is empty
+func New(path string) (*Calendar, error) {
+	location, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		return nil, err
+	}
+
+	var source io.Reader = strings.NewReader(bundledHolidays)
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open holiday file: %v", err)
+		}
+		defer file.Close()
+		source = file
+	}
+
+	holidays, err := parseHolidays(source)
+	if err != nil {
+		return nil, err
+	}
+	return &Calendar{location: location, holidays: holidays}, nil
+}
+
+// parseHolidays reads "YYYY-MM-DD Description" lines, ignoring blanks and # comments
+func parseHolidays(r io.Reader) (map[string]string, error) {
+	holidays := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		date, name, _ := strings.Cut(text, " ")
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, fmt.Errorf("invalid holiday date on line %d: %q", line, date)
+		}
+		holidays[date] = strings.TrimSpace(name)
+	}
+	return holidays, scanner.Err()
+}
+
+// Default returns the calendar from MARKET_HOLIDAYS_FILE, falling back to
+// the bundled holiday list if that file cannot be read
+func Default() *Calendar {
+	cfg := config.GetConfig()
+	cal, err := New(cfg.MarketHolidaysFile)
+	if err != nil {
+		fmt.Printf("Warning: %v, using bundled holiday list\n", err)
+		cal, err = New("")
+		if err != nil {
+			panic("bundled holiday list is invalid: " + err.Error())
+		}
+	}
+	return cal
+}
+
+// Location returns the exchange time zone
+func (c *Calendar) Location() *time.Location {
+	return c.location
+}
+
+// Holiday returns the holiday name if the exchange is closed for a holiday on t's date
+func (c *Calendar) Holiday(t time.Time) (string, bool) {
+	name, ok := c.holidays[t.In(c.location).Format("2006-01-02")]
+	return name, ok
+}
+
+// IsTradingDay reports whether the exchange trades on t's date
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	local := t.In(c.location)
+	if local.Weekday() == time.Saturday || local.Weekday() == time.Sunday {
+		return false
+	}
+	_, holiday := c.Holiday(local)
+	return !holiday
+}
+
+// PhaseAt returns the session phase at time t
+func (c *Calendar) PhaseAt(t time.Time) Phase {
+	if !c.IsTradingDay(t) {
+		return PhaseClosed
+	}
+	local := t.In(c.location)
+	offset := local.Sub(startOfDay(local))
+	for _, session := range Sessions {
+		if offset >= session.Start && offset < session.End {
+			return session.Phase
+		}
+	}
+	return PhaseClosed
+}
+
+// LastClose returns the date of the most recent completed regular session at time t
+func (c *Calendar) LastClose(t time.Time) time.Time {
+	local := t.In(c.location)
+	day := startOfDay(local)
+
+	// Today only counts once the regular session has ended
+	if !c.IsTradingDay(day) || local.Sub(day) < RegularSession.End {
+		day = day.AddDate(0, 0, -1)
+	}
+	for !c.IsTradingDay(day) {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// Status describes the market at a point in time
+type Status struct {
+	TradingDay bool      // Whether the exchange trades today at all
+	Phase      Phase     // Current session phase
+	Reason     string    // Why the market is shut today (weekend or holiday name)
+	LastClose  time.Time // Date of the most recent completed regular session
+	TodaysData bool      // Whether today's regular session has started, so prices are from today
+}
+
+// StatusAt returns the market status at time t
+func (c *Calendar) StatusAt(t time.Time) Status {
+	local := t.In(c.location)
+	status := Status{
+		TradingDay: c.IsTradingDay(local),
+		Phase:      c.PhaseAt(local),
+		LastClose:  c.LastClose(local),
+	}
+
+	status.TodaysData = status.TradingDay && local.Sub(startOfDay(local)) >= RegularSession.Start
+	if name, ok := c.Holiday(local); ok {
+		status.Reason = name
+	} else if !status.TradingDay {
+		status.Reason = "weekend"
+	}
+	return status
+}
+
+// Label returns a report suffix such as "last close 24-Oct-2025" when the
+// data is not from today's session, or an empty string when it is
+func (s Status) Label() string {
+	if s.TodaysData {
+		return ""
+	}
+	return "last close " + s.LastClose.Format("02-Jan-2006")
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,159 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// at parses "2006-01-02 15:04" in exchange time
+func at(t *testing.T, cal *Calendar, value string) time.Time {
+	t.Helper()
+	tm, err := time.ParseInLocation("2006-01-02 15:04", value, cal.Location())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tm
+}
+
+func bundled(t *testing.T) *Calendar {
+	t.Helper()
+	cal, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cal
+}
+
+func TestPhaseAt(t *testing.T) {
+	cal := bundled(t)
+	tests := []struct {
+		at   string
+		want Phase
+	}{
+		{"2025-06-11 08:59", PhaseClosed},
+		{"2025-06-11 09:00", PhasePreOpen},
+		{"2025-06-11 09:14", PhasePreOpen},
+		{"2025-06-11 09:15", PhaseRegular},
+		{"2025-06-11 15:29", PhaseRegular},
+		{"2025-06-11 15:30", PhaseClosed},
+		{"2025-06-11 15:40", PhaseClosing},
+		{"2025-06-11 16:00", PhaseClosed},
+		{"2025-06-14 10:00", PhaseClosed}, // Saturday
+		{"2025-08-15 10:00", PhaseClosed}, // Independence Day
+	}
+
+	for _, tt := range tests {
+		if got := cal.PhaseAt(at(t, cal, tt.at)); got != tt.want {
+			t.Errorf("PhaseAt(%s) = %s, want %s", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestLastClose(t *testing.T) {
+	cal := bundled(t)
+	tests := []struct {
+		name string
+		at   string
+		want string
+	}{
+		{"after the close", "2025-06-11 15:30", "2025-06-11"},
+		{"during the session", "2025-06-11 15:29", "2025-06-10"},
+		{"before the open", "2025-06-11 08:00", "2025-06-10"},
+		{"Monday morning", "2025-06-16 09:00", "2025-06-13"},
+		{"Sunday", "2025-06-15 18:00", "2025-06-13"},
+		{"Saturday", "2025-06-14 18:00", "2025-06-13"},
+		{"after a Friday holiday", "2025-04-21 10:00", "2025-04-17"},
+		{"on a holiday", "2025-08-15 18:00", "2025-08-14"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.LastClose(at(t, cal, tt.at)).Format("2006-01-02"); got != tt.want {
+				t.Errorf("LastClose(%s) = %s, want %s", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLastCloseUTC checks that times in other zones are read in exchange
+// time: 11:00 UTC is 16:30 IST, after the close
+func TestLastCloseUTC(t *testing.T) {
+	cal := bundled(t)
+	utc := time.Date(2025, 6, 11, 11, 0, 0, 0, time.UTC)
+	if got := cal.LastClose(utc).Format("2006-01-02"); got != "2025-06-11" {
+		t.Errorf("LastClose(%s) = %s, want 2025-06-11", utc, got)
+	}
+}
+
+func TestStatusAt(t *testing.T) {
+	cal := bundled(t)
+	tests := []struct {
+		name   string
+		at     string
+		reason string
+		label  string
+	}{
+		{"trading", "2025-06-11 12:00", "", ""},
+		{"before the open", "2025-06-11 09:00", "", "last close 10-Jun-2025"},
+		{"weekend", "2025-06-14 12:00", "weekend", "last close 13-Jun-2025"},
+		{"holiday", "2025-08-15 12:00", "Independence Day", "last close 14-Aug-2025"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := cal.StatusAt(at(t, cal, tt.at))
+			if status.Reason != tt.reason {
+				t.Errorf("Reason = %q, want %q", status.Reason, tt.reason)
+			}
+			if got := status.Label(); got != tt.label {
+				t.Errorf("Label() = %q, want %q", got, tt.label)
+			}
+		})
+	}
+}
+
+func TestParseHolidays(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "comments and blanks",
+			input: "# 2025\n\n2025-08-15 Independence Day\n  2025-12-25   Christmas  \n",
+			want:  map[string]string{"2025-08-15": "Independence Day", "2025-12-25": "Christmas"},
+		},
+		{
+			name:  "no description",
+			input: "2025-08-15",
+			want:  map[string]string{"2025-08-15": ""},
+		},
+		{
+			name:    "invalid date",
+			input:   "2025-08-15 Independence Day\n15-08-2025 Independence Day",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHolidays(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHolidays() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseHolidays() = %v, want %v", got, tt.want)
+			}
+			for date, name := range tt.want {
+				if got[date] != name {
+					t.Errorf("holiday %s = %q, want %q", date, got[date], name)
+				}
+			}
+		})
+	}
+}
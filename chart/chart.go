@@ -0,0 +1,352 @@
+// Package chart draws candlestick charts as PNG images with the standard
+// image packages, labelled with the x/image basic bitmap font.
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"go-stock/indicators"
+)
+
+// Chart size and layout, in pixels
+const (
+	width       = 800
+	height      = 600
+	marginLeft  = 10
+	marginRight = 64 // Room for the price axis labels
+	titleHeight = 24
+	dateHeight  = 18
+	panelGap    = 8
+
+	// DefaultBars is how many of the most recent sessions are drawn
+	DefaultBars = 60
+)
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	gridColor  = color.RGBA{0xe6, 0xe6, 0xe6, 0xff}
+	textColor  = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	upColor    = color.RGBA{0x26, 0xa6, 0x9a, 0xff}
+	downColor  = color.RGBA{0xef, 0x53, 0x50, 0xff}
+	maShort    = color.RGBA{0x1e, 0x88, 0xe5, 0xff}
+	maLong     = color.RGBA{0xfb, 0x8c, 0x00, 0xff}
+	rsiColor   = color.RGBA{0x8e, 0x24, 0xaa, 0xff}
+	levelColor = color.RGBA{0xbd, 0xbd, 0xbd, 0xff}
+)
+
+// Candlestick draws the last DefaultBars sessions of history as a PNG:
+// candles with short and long moving averages, volume bars beneath, and an
+// RSI panel at the bottom. The full history is used to warm up the
+// indicators, so pass as much as is available.
+func Candlestick(title string, history indicators.Series, settings indicators.Settings) ([]byte, error) {
+	if len(history) < 2 {
+		return nil, fmt.Errorf("need at least 2 bars to draw a chart, have %d", len(history))
+	}
+
+	closes := history.Closes()
+	maShortLine := rolling(closes, func(c []float64) float64 { return indicators.SMA(c, settings.MAShortPeriod) }, settings.MAShortPeriod)
+	maLongLine := rolling(closes, func(c []float64) float64 { return indicators.SMA(c, settings.MALongPeriod) }, settings.MALongPeriod)
+	rsiLine := rolling(closes, func(c []float64) float64 { return indicators.RSI(c, settings.RSIPeriod) }, settings.RSIPeriod+1)
+
+	// Only the most recent sessions are drawn
+	start := 0
+	if len(history) > DefaultBars {
+		start = len(history) - DefaultBars
+	}
+	bars := history[start:]
+	maShortLine, maLongLine, rsiLine = maShortLine[start:], maLongLine[start:], rsiLine[start:]
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+
+	// Split the area below the title into price, volume and RSI panels (60/15/25)
+	plotTop := titleHeight
+	plotBottom := height - dateHeight
+	available := plotBottom - plotTop - 2*panelGap
+	pricePanel := image.Rect(marginLeft, plotTop, width-marginRight, plotTop+available*60/100)
+	volumePanel := image.Rect(marginLeft, pricePanel.Max.Y+panelGap, width-marginRight, pricePanel.Max.Y+panelGap+available*15/100)
+	rsiPanel := image.Rect(marginLeft, volumePanel.Max.Y+panelGap, width-marginRight, plotBottom)
+
+	c := &canvas{img: img, slots: len(bars)}
+	c.drawPrices(pricePanel, bars, maShortLine, maLongLine)
+	c.drawVolume(volumePanel, bars)
+	c.drawRSI(rsiPanel, rsiLine)
+	c.drawDates(plotBottom, pricePanel, bars)
+
+	c.text(marginLeft, 16, textColor, title)
+	c.text(width-marginRight-150, 16, maShort, fmt.Sprintf("MA%d", settings.MAShortPeriod))
+	c.text(width-marginRight-100, 16, maLong, fmt.Sprintf("MA%d", settings.MALongPeriod))
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rolling evaluates f over every prefix of values, with NaN until minLength values are available
+func rolling(values []float64, f func([]float64) float64, minLength int) []float64 {
+	line := make([]float64, len(values))
+	for i := range values {
+		if i+1 < minLength {
+			line[i] = math.NaN()
+			continue
+		}
+		line[i] = f(values[:i+1])
+	}
+	return line
+}
+
+// canvas draws into img, with one horizontal slot per bar
+type canvas struct {
+	img   *image.RGBA
+	slots int
+}
+
+// x returns the centre of slot i within panel
+func (c *canvas) x(panel image.Rectangle, i int) int {
+	slot := float64(panel.Dx()) / float64(c.slots)
+	return panel.Min.X + int(slot*(float64(i)+0.5))
+}
+
+// bodyWidth is the width of a candle body or volume bar
+func (c *canvas) bodyWidth(panel image.Rectangle) int {
+	w := panel.Dx() * 6 / 10 / c.slots
+	if w < 1 {
+		return 1
+	}
+	return w
+}
+
+func (c *canvas) drawPrices(panel image.Rectangle, bars indicators.Series, lines ...[]float64) {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, bar := range bars {
+		low, high = math.Min(low, bar.Low), math.Max(high, bar.High)
+	}
+	for _, line := range lines {
+		for _, v := range line {
+			if !math.IsNaN(v) {
+				low, high = math.Min(low, v), math.Max(high, v)
+			}
+		}
+	}
+	pad := (high - low) * 0.05
+	if pad == 0 {
+		pad = high * 0.01
+	}
+	s := scale{panel: panel, min: low - pad, max: high + pad}
+	c.grid(panel, s, 5, priceLabel(s.max-s.min))
+
+	half := c.bodyWidth(panel) / 2
+	for i, bar := range bars {
+		col := upColor
+		if bar.Close < bar.Open {
+			col = downColor
+		}
+		x := c.x(panel, i)
+		c.vline(x, s.y(bar.High), s.y(bar.Low), col)
+
+		top, bottom := s.y(math.Max(bar.Open, bar.Close)), s.y(math.Min(bar.Open, bar.Close))
+		if bottom == top {
+			bottom++ // Doji: draw a visible line
+		}
+		c.fill(image.Rect(x-half, top, x+half+1, bottom), col)
+	}
+
+	colors := []color.RGBA{maShort, maLong}
+	for n, line := range lines {
+		c.polyline(panel, s, line, colors[n%len(colors)])
+	}
+}
+
+func (c *canvas) drawVolume(panel image.Rectangle, bars indicators.Series) {
+	var peak int64
+	for _, bar := range bars {
+		if bar.Volume > peak {
+			peak = bar.Volume
+		}
+	}
+	if peak == 0 {
+		peak = 1
+	}
+	s := scale{panel: panel, min: 0, max: float64(peak)}
+	c.text(panel.Max.X+4, panel.Min.Y+10, textColor, "Vol")
+	c.text(panel.Max.X+4, panel.Min.Y+24, textColor, volumeLabel(peak))
+
+	half := c.bodyWidth(panel) / 2
+	for i, bar := range bars {
+		col := upColor
+		if bar.Close < bar.Open {
+			col = downColor
+		}
+		x := c.x(panel, i)
+		c.fill(image.Rect(x-half, s.y(float64(bar.Volume)), x+half+1, panel.Max.Y), col)
+	}
+}
+
+func (c *canvas) drawRSI(panel image.Rectangle, rsi []float64) {
+	s := scale{panel: panel, min: 0, max: 100}
+	for _, level := range []float64{30, 70} {
+		y := s.y(level)
+		for x := panel.Min.X; x < panel.Max.X; x += 6 {
+			c.hline(x, x+3, y, levelColor)
+		}
+		c.text(panel.Max.X+4, y+4, textColor, fmt.Sprintf("%.0f", level))
+	}
+	c.outline(panel)
+	c.text(panel.Min.X+4, panel.Min.Y+12, rsiColor, "RSI")
+	c.polyline(panel, s, rsi, rsiColor)
+}
+
+// drawDates labels the first, middle and last sessions under the chart
+func (c *canvas) drawDates(y int, panel image.Rectangle, bars indicators.Series) {
+	for _, i := range []int{0, len(bars) / 2, len(bars) - 1} {
+		label := bars[i].Time.Format("02 Jan")
+		x := c.x(panel, i) - len(label)*7/2
+		if x < 0 {
+			x = 0
+		}
+		c.text(x, y+14, textColor, label)
+	}
+}
+
+// grid draws horizontal grid lines with labels on the right axis
+func (c *canvas) grid(panel image.Rectangle, s scale, lines int, format string) {
+	for i := 0; i <= lines; i++ {
+		v := s.min + (s.max-s.min)*float64(i)/float64(lines)
+		y := s.y(v)
+		c.hline(panel.Min.X, panel.Max.X, y, gridColor)
+		c.text(panel.Max.X+4, y+4, textColor, fmt.Sprintf(format, v))
+	}
+	c.outline(panel)
+}
+
+// polyline joins consecutive values, skipping NaN gaps
+func (c *canvas) polyline(panel image.Rectangle, s scale, values []float64, col color.RGBA) {
+	for i := 1; i < len(values); i++ {
+		if math.IsNaN(values[i-1]) || math.IsNaN(values[i]) {
+			continue
+		}
+		x0, y0 := c.x(panel, i-1), s.y(values[i-1])
+		x1, y1 := c.x(panel, i), s.y(values[i])
+		c.line(x0, y0, x1, y1, col)
+		c.line(x0, y0+1, x1, y1+1, col) // 2px wide
+	}
+}
+
+// line draws a straight line with Bresenham's algorithm
+func (c *canvas) line(x0, y0, x1, y1 int, col color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		c.img.SetRGBA(x0, y0, col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			x0 += sx
+		} else {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func (c *canvas) hline(x0, x1, y int, col color.RGBA) {
+	for x := x0; x < x1; x++ {
+		c.img.SetRGBA(x, y, col)
+	}
+}
+
+func (c *canvas) vline(x, y0, y1 int, col color.RGBA) {
+	for y := y0; y <= y1; y++ {
+		c.img.SetRGBA(x, y, col)
+	}
+}
+
+func (c *canvas) fill(r image.Rectangle, col color.RGBA) {
+	draw.Draw(c.img, r, &image.Uniform{col}, image.Point{}, draw.Src)
+}
+
+func (c *canvas) outline(r image.Rectangle) {
+	c.hline(r.Min.X, r.Max.X, r.Min.Y, gridColor)
+	c.hline(r.Min.X, r.Max.X, r.Max.Y-1, gridColor)
+	c.vline(r.Min.X, r.Min.Y, r.Max.Y-1, gridColor)
+	c.vline(r.Max.X-1, r.Min.Y, r.Max.Y-1, gridColor)
+}
+
+// text draws s with its baseline at y
+func (c *canvas) text(x, y int, col color.RGBA, s string) {
+	d := font.Drawer{
+		Dst:  c.img,
+		Src:  &image.Uniform{col},
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+}
+
+// scale maps values onto the vertical extent of a panel
+type scale struct {
+	panel    image.Rectangle
+	min, max float64
+}
+
+func (s scale) y(v float64) int {
+	if s.max == s.min {
+		return s.panel.Min.Y + s.panel.Dy()/2
+	}
+	frac := (v - s.min) / (s.max - s.min)
+	return s.panel.Max.Y - 1 - int(frac*float64(s.panel.Dy()-1))
+}
+
+// priceLabel picks how many decimals the price axis needs for a given range
+func priceLabel(span float64) string {
+	switch {
+	case span >= 50:
+		return "%.0f"
+	case span >= 5:
+		return "%.1f"
+	default:
+		return "%.2f"
+	}
+}
+
+// volumeLabel abbreviates a volume in Indian units, e.g. 12.3L or 1.2Cr
+func volumeLabel(v int64) string {
+	switch {
+	case v >= 10000000:
+		return fmt.Sprintf("%.1fCr", float64(v)/10000000)
+	case v >= 100000:
+		return fmt.Sprintf("%.1fL", float64(v)/100000)
+	case v >= 1000:
+		return fmt.Sprintf("%.1fK", float64(v)/1000)
+	default:
+		return fmt.Sprintf("%d", v)
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
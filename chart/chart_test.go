@@ -0,0 +1,99 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"testing"
+	"time"
+
+	"go-stock/indicators"
+)
+
+// series returns n daily bars whose close is price(i)
+func series(n int, price func(i int) float64) indicators.Series {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	bars := make(indicators.Series, n)
+	for i := range bars {
+		close := price(i)
+		bars[i] = indicators.Bar{Time: start.AddDate(0, 0, i), Open: close, High: close + 1, Low: close - 1, Close: close, Volume: int64(1000 * (i + 1))}
+	}
+	return bars
+}
+
+func TestCandlestick(t *testing.T) {
+	tests := []struct {
+		name    string
+		history indicators.Series
+		wantErr bool
+	}{
+		{name: "full history", history: series(200, func(i int) float64 { return 1000 + 50*math.Sin(float64(i)/7) })},
+		{name: "fewer bars than the indicator periods", history: series(3, func(i int) float64 { return 100 + float64(i) })},
+		{name: "two bars", history: series(2, func(i int) float64 { return 100 })},
+		{name: "flat", history: series(80, func(i int) float64 { return 250 })},
+		{name: "no volume or range", history: func() indicators.Series {
+			bars := series(30, func(i int) float64 { return 10 })
+			for i := range bars {
+				bars[i].High, bars[i].Low, bars[i].Volume = 10, 10, 0
+			}
+			return bars
+		}()},
+		{name: "one bar", history: series(1, func(i int) float64 { return 100 }), wantErr: true},
+		{name: "empty", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Candlestick("TCS.NS", tt.history, indicators.DefaultSettings())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Candlestick() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Candlestick() is not a PNG: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != width || b.Dy() != height {
+				t.Errorf("image is %dx%d, want %dx%d", b.Dx(), b.Dy(), width, height)
+			}
+		})
+	}
+}
+
+// TestCandlestickDeterministic checks that the same history always encodes
+// to the same bytes, which replayed uploads rely on
+func TestCandlestickDeterministic(t *testing.T) {
+	history := series(100, func(i int) float64 { return 500 + float64(i%13) })
+	first, err := Candlestick("INFY.NS", history, indicators.DefaultSettings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Candlestick("INFY.NS", history, indicators.DefaultSettings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Errorf("Candlestick() encoded the same history differently")
+	}
+}
+
+func TestVolumeLabel(t *testing.T) {
+	tests := []struct {
+		volume int64
+		want   string
+	}{
+		{999, "999"},
+		{1500, "1.5K"},
+		{1230000, "12.3L"},
+		{12000000, "1.2Cr"},
+	}
+
+	for _, tt := range tests {
+		if got := volumeLabel(tt.volume); got != tt.want {
+			t.Errorf("volumeLabel(%d) = %q, want %q", tt.volume, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Package chatlist stores the personal watchlist each Telegram chat manages
+// through the bot.
+package chatlist
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"go-stock/config"
+	"go-stock/fileutil"
+)
+
+// MaxSymbols caps the size of one chat's watchlist
+const MaxSymbols = 25
+
+// Store persists chat watchlists in a JSON file, keyed by chat ID. Changes
+// are serialised within the process by the store and between processes
+// (e.g. the bot and a stock run) by a lock file next to it.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// stores holds the one Store for each file, so every caller shares its lock
+var (
+	stores   = make(map[string]*Store)
+	storesMu sync.Mutex
+)
+
+// Open returns the store backed by the given file, which is created on first write
+func Open(path string) *Store {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	if s, ok := stores[path]; ok {
+		return s
+	}
+	s := &Store{path: path}
+	stores[path] = s
+	return s
+}
+
+// Default returns the store at the configured path
+func Default() *Store {
+	return Open(config.GetConfig().ChatWatchlistsFile)
+}
+
+// List returns a chat's symbols in the order they were added
+func (s *Store) List(chatID string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lists, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return lists[chatID], nil
+}
+
+// All returns every chat's symbols
+func (s *Store) All() (map[string][]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Add appends symbols to a chat's watchlist and returns those that were not
+// already on it. Symbols beyond MaxSymbols are not added.
+func (s *Store) Add(chatID string, symbols ...string) ([]string, error) {
+	var added []string
+	err := s.update(func(lists map[string][]string) bool {
+		for _, symbol := range symbols {
+			if contains(lists[chatID], symbol) || len(lists[chatID]) >= MaxSymbols {
+				continue
+			}
+			lists[chatID] = append(lists[chatID], symbol)
+			added = append(added, symbol)
+		}
+		return len(added) > 0
+	})
+	return added, err
+}
+
+// Remove deletes symbols from a chat's watchlist and returns those that were on it
+func (s *Store) Remove(chatID string, symbols ...string) ([]string, error) {
+	var removed []string
+	err := s.update(func(lists map[string][]string) bool {
+		var kept []string
+		for _, symbol := range lists[chatID] {
+			if contains(symbols, symbol) {
+				removed = append(removed, symbol)
+			} else {
+				kept = append(kept, symbol)
+			}
+		}
+		if len(removed) == 0 {
+			return false
+		}
+		if len(kept) == 0 {
+			delete(lists, chatID)
+		} else {
+			lists[chatID] = kept
+		}
+		return true
+	})
+	return removed, err
+}
+
+// update loads the file under both locks, lets change modify it and saves
+// it if change reports a modification
+func (s *Store) update(change func(lists map[string][]string) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	unlock, err := fileutil.Lock(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	lists, err := s.load()
+	if err != nil {
+		return err
+	}
+	if !change(lists) {
+		return nil
+	}
+	return s.save(lists)
+}
+
+// load reads the file; a missing file is an empty store
+func (s *Store) load() (map[string][]string, error) {
+	lists := make(map[string][]string)
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return lists, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &lists); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// save writes the file
+func (s *Store) save(lists map[string][]string) error {
+	data, err := json.MarshalIndent(lists, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFile(s.path, data, 0o644)
+}
+
+// ChatIDs returns the chats that have a watchlist, sorted
+func ChatIDs(lists map[string][]string) []string {
+	ids := make([]string, 0, len(lists))
+	for id := range lists {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
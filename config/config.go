@@ -1,70 +1,496 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
+
+	"go-stock/indicators"
 )
 
 // Config holds all configuration values
 type Config struct {
-	TelegramBotToken string
-	TelegramChatIDs  []string
-	GeminiAPIKey     string
-	StockList        string
+	TelegramBotToken   string
+	TelegramChatIDs    []string
+	TelegramParseMode  string // "MarkdownV2" or "HTML"
+	LLM                LLMSettings
+	InsightsMode       string // "ai" (falling back to rules) or "rules"
+	PromptDir          string // Optional directory of prompt templates replacing the bundled ones
+	Watchlists         []Watchlist
+	Indicators         indicators.Settings
+	MissingBarPolicy   string            // "drop" or "ffill" for bars with no close price
+	HTTPMode           string            // "live", "record" or "replay"
+	FixtureDir         string            // Where recorded HTTP fixtures are stored
+	Timezone           string            // Time zone used by the daemon scheduler
+	Schedules          map[string]string // Cron expression per job name
+	MarketHolidaysFile string            // Optional replacement for the bundled NSE holiday list
+	MarketClosedPolicy string            // "skip" or "label" when a job runs while the market is shut
+	MarketCap          MarketCapSettings
+	Notifiers          map[string]NotifierConfig // Named notification backends
+	MarketFallNotify   []string                  // Notifiers for the market fall check
+	ScorecardNotify    []string                  // Notifiers for the weekly recommendation scorecard
+	ChatWatchlistsFile string                    // Where personal watchlists managed through the bot are kept
+	StoreFile          string                    // Database keeping the history of every run
+}
+
+// NotifierConfig configures one notification backend. String values may
+// reference environment variables as ${NAME} to keep secrets out of the file.
+type NotifierConfig struct {
+	Type string `yaml:"type"` // telegram, slack, discord, email or webhook
+
+	// telegram: defaults to TELEGRAM_BOT_TOKEN, the watchlist's chats and TELEGRAM_PARSE_MODE
+	BotToken  string   `yaml:"bot_token"`
+	ChatIDs   []string `yaml:"chat_ids"`
+	ParseMode string   `yaml:"parse_mode"`
+
+	// slack, discord
+	WebhookURL string `yaml:"webhook_url"`
+
+	// webhook
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+
+	// email
+	SMTPHost string   `yaml:"smtp_host"`
+	SMTPPort int      `yaml:"smtp_port"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
 }
 
-// Default stock lists by market cap
+// LLMSettings selects the model that writes the AI insights
+type LLMSettings struct {
+	Provider string `yaml:"provider"` // gemini, openai or ollama
+	Model    string `yaml:"model"`    // e.g. gemini-2.5-flash, gpt-4o-mini, llama3.1
+	BaseURL  string `yaml:"base_url"` // Endpoint for OpenAI-compatible servers and Ollama
+	APIKey   string `yaml:"api_key"`  // Defaults to GEMINI_API_KEY for gemini
+	Batch    bool   `yaml:"batch"`    // Ask for a whole group of stocks in one request
+
+	CacheDir string        `yaml:"cache_dir"` // Where model replies are cached between runs
+	CacheTTL time.Duration `yaml:"cache_ttl"` // How long a cached reply is reused, e.g. 12h
+}
+
+// Default model and endpoint for each LLM provider
+var (
+	DefaultLLMModels = map[string]string{
+		"gemini": "gemini-2.5-flash",
+		"openai": "gpt-4o-mini",
+		"ollama": "llama3.1",
+	}
+	DefaultLLMBaseURLs = map[string]string{
+		"gemini": "https://generativelanguage.googleapis.com/v1beta",
+		"openai": "https://api.openai.com/v1",
+		"ollama": "http://localhost:11434",
+	}
+)
+
+// Defaults for the model reply cache
 const (
-	// Large Cap Stocks (Market Cap > ₹50,000 Cr)
-	DefaultLargeCapStocks = "RELIANCE.NS,TCS.NS,HDFCBANK.NS,INFY.NS,ICICIBANK.NS"
+	DefaultLLMCacheDir = "data/llm_cache"
+	DefaultLLMCacheTTL = 12 * time.Hour
+)
+
+// DefaultNotify is used by watchlists and jobs that do not list notifiers
+var DefaultNotify = []string{"telegram"}
+
+// MarketCapSettings controls automatic large/mid/small cap classification
+type MarketCapSettings struct {
+	AMFIFile         string  `yaml:"amfi_file"`           // AMFI classification CSV (path or URL)
+	CacheFile        string  `yaml:"cache_file"`          // Where computed classes are cached
+	RefreshDays      int     `yaml:"refresh_days"`        // How long a cached class is trusted
+	LargeCapMinCrore float64 `yaml:"large_cap_min_crore"` // Market cap of the 100th company, used without AMFI data
+	MidCapMinCrore   float64 `yaml:"mid_cap_min_crore"`   // Market cap of the 250th company, used without AMFI data
+}
+
+// DefaultTimezone is the scheduler time zone (NSE/BSE local time)
+const DefaultTimezone = "Asia/Kolkata"
+
+// DefaultSchedules holds the cron expression for every job the daemon can run.
+// Each can be overridden with <JOB>_CRON, or disabled with <JOB>_CRON=off.
+var DefaultSchedules = map[string]string{
+	"stock":      "30 16 * * 1-5", // 4:30 PM on weekdays, after the close
+	"marketfall": "45 16 * * 1-5", // 4:45 PM on weekdays
+	"scorecard":  "0 18 * * 5",    // 6:00 PM on Fridays
+}
 
-	// Mid Cap Stocks (Market Cap ₹10,000-50,000 Cr)
-	DefaultMidCapStocks = "TATAMOTORS.NS,ADANIENT.NS,BAJFINANCE.NS,TITAN.NS,MARICO.NS"
+// DefaultChatWatchlistsFile stores the watchlists chats manage with /add and /remove
+const DefaultChatWatchlistsFile = "data/chat_watchlists.json"
 
-	// Small Cap Stocks (Market Cap < ₹10,000 Cr)
-	DefaultSmallCapStocks = "JUBLFOOD.NS,FORTIS.NS,KALYANKJIL.NS,SUPREMEIND.NS,VBL.NS"
+// DefaultStoreFile keeps the history of quotes, metrics, recommendations and deliveries
+const DefaultStoreFile = "data/stock.db"
 
-	// Combined default stock list
-	DefaultStockList = DefaultLargeCapStocks + "," + DefaultMidCapStocks + "," + DefaultSmallCapStocks
+// Default market-cap classification settings. The cut-offs approximate the
+// 100th and 250th ranked companies in AMFI's list and should be updated when
+// AMFI publishes a new one (January and July).
+const (
+	DefaultMarketCapCacheFile   = "data/marketcap.json"
+	DefaultMarketCapRefreshDays = 30
+	DefaultLargeCapMinCrore     = 100000
+	DefaultMidCapMinCrore       = 33000
 )
 
-// GetConfig retrieves configuration values from environment variables
+// GetConfig retrieves configuration values from the config file (CONFIG_FILE,
+// default config.yaml) with environment variables taking precedence
 func GetConfig() *Config {
+	file := loadFile()
+
 	// Ensure Telegram credentials are properly formatted
-	botToken := os.Getenv("TELEGRAM_BOT_TOKEN")
-	chatIDsStr := os.Getenv("TELEGRAM_CHAT_IDS")
+	botToken := strings.TrimSpace(lookup("TELEGRAM_BOT_TOKEN", file.Telegram.BotToken))
 
-	// Remove any whitespace from credentials
-	if botToken != "" {
-		botToken = strings.TrimSpace(botToken)
+	// Split and clean chat IDs
+	chatIDs := cleanChatIDs(file.Telegram.ChatIDs)
+	if chatIDsStr := strings.TrimSpace(os.Getenv("TELEGRAM_CHAT_IDS")); chatIDsStr != "" {
+		chatIDs = cleanChatIDs(strings.Split(chatIDsStr, ","))
 	}
 
-	// Split and clean chat IDs
+	indicatorEnv := getIndicatorEnv()
+	settings := file.Indicators.Override(indicatorEnv).WithDefaults()
+
+	return &Config{
+		TelegramBotToken:   botToken,
+		TelegramChatIDs:    chatIDs,
+		TelegramParseMode:  getParseMode(lookup("TELEGRAM_PARSE_MODE", file.Telegram.ParseMode)),
+		LLM:                getLLMSettings(file.LLM, lookup("GEMINI_API_KEY", file.GeminiAPIKey)),
+		InsightsMode:       getInsightsMode(file.InsightsMode),
+		PromptDir:          strings.TrimSpace(lookup("PROMPT_DIR", file.PromptDir)),
+		Watchlists:         getWatchlists(file.Watchlists, settings, indicatorEnv, chatIDs),
+		Indicators:         settings,
+		MissingBarPolicy:   getMissingBarPolicy(file.MissingBarPolicy),
+		HTTPMode:           getHTTPMode(),
+		FixtureDir:         getEnvOrDefault("HTTP_FIXTURE_DIR", "fixtures"),
+		Timezone:           getEnvOrDefault("SCHEDULER_TIMEZONE", orDefault(file.Timezone, DefaultTimezone)),
+		Schedules:          getSchedules(file.Schedules),
+		MarketHolidaysFile: strings.TrimSpace(lookup("MARKET_HOLIDAYS_FILE", file.MarketHolidaysFile)),
+		MarketClosedPolicy: getMarketClosedPolicy(file.MarketClosedPolicy),
+		MarketCap:          getMarketCapSettings(file.MarketCap),
+		Notifiers:          getNotifiers(file.Notifiers),
+		MarketFallNotify:   orDefaultList(file.MarketFall.Notify, DefaultNotify),
+		ScorecardNotify:    orDefaultList(file.Scorecard.Notify, DefaultNotify),
+		ChatWatchlistsFile: strings.TrimSpace(lookup("CHAT_WATCHLISTS_FILE", orDefault(file.ChatWatchlistsFile, DefaultChatWatchlistsFile))),
+		StoreFile:          strings.TrimSpace(lookup("STORE_FILE", orDefault(file.StoreFile, DefaultStoreFile))),
+	}
+}
+
+// cleanChatIDs trims chat IDs and drops empty entries
+func cleanChatIDs(rawChatIDs []string) []string {
 	var chatIDs []string
-	if chatIDsStr != "" {
-		chatIDsStr = strings.TrimSpace(chatIDsStr)
-		rawChatIDs := strings.Split(chatIDsStr, ",")
-		for _, id := range rawChatIDs {
-			if trimmedID := strings.TrimSpace(id); trimmedID != "" {
-				chatIDs = append(chatIDs, trimmedID)
-			}
+	for _, id := range rawChatIDs {
+		if trimmedID := strings.TrimSpace(id); trimmedID != "" {
+			chatIDs = append(chatIDs, trimmedID)
 		}
 	}
+	return chatIDs
+}
 
-	return &Config{
-		TelegramBotToken: botToken,
-		TelegramChatIDs:  chatIDs,
-		GeminiAPIKey:     os.Getenv("GEMINI_API_KEY"),
-		StockList:        getStockList(),
+// getWatchlists returns the configured watchlists, with STOCK_LIST overriding
+// their symbols. Each watchlist's indicator settings are layered over the
+// global settings, with the environment's periods taking precedence over
+// both, and its chats default to the global chat list.
+func getWatchlists(fromFile []Watchlist, settings, indicatorEnv indicators.Settings, chatIDs []string) []Watchlist {
+	watchlists, otherIndex := fromFile, -1
+	if len(watchlists) == 0 {
+		// Without a config file every symbol goes in the single default watchlist
+		watchlists, otherIndex = DefaultWatchlists, 0
+	}
+	if stockList := os.Getenv("STOCK_LIST"); stockList != "" {
+		watchlists = watchlistsFromStockList(stockList, watchlists, otherIndex)
+	}
+
+	resolved := make([]Watchlist, len(watchlists))
+	for i, w := range watchlists {
+		w.Indicators = settings.Override(w.Indicators).Override(indicatorEnv)
+		if len(w.ChatIDs) == 0 {
+			w.ChatIDs = chatIDs
+		} else {
+			w.ChatIDs = cleanChatIDs(w.ChatIDs)
+		}
+		w.Notify = orDefaultList(w.Notify, DefaultNotify)
+		resolved[i] = w
 	}
+	return resolved
 }
 
-// getStockList returns the stock list from environment variable or default list
-func getStockList() string {
-	if stockList := os.Getenv("STOCK_LIST"); stockList != "" {
-		return stockList
+// getNotifiers expands ${ENV} references in every notifier setting
+func getNotifiers(fromFile map[string]NotifierConfig) map[string]NotifierConfig {
+	notifiers := make(map[string]NotifierConfig)
+	for name, n := range fromFile {
+		n.Type = strings.ToLower(strings.TrimSpace(n.Type))
+		n.BotToken = os.ExpandEnv(n.BotToken)
+		n.WebhookURL = os.ExpandEnv(n.WebhookURL)
+		n.URL = os.ExpandEnv(n.URL)
+		n.SMTPHost = os.ExpandEnv(n.SMTPHost)
+		n.Username = os.ExpandEnv(n.Username)
+		n.Password = os.ExpandEnv(n.Password)
+		n.From = os.ExpandEnv(n.From)
+		n.ChatIDs = cleanChatIDs(n.ChatIDs)
+		if n.ParseMode != "" {
+			n.ParseMode = getParseMode(n.ParseMode)
+		}
+
+		headers := make(map[string]string)
+		for key, value := range n.Headers {
+			headers[key] = os.ExpandEnv(value)
+		}
+		n.Headers = headers
+		notifiers[name] = n
+	}
+	return notifiers
+}
+
+// getLLMSettings returns the model settings from the config file and
+// LLM_* environment variables, filling in each provider's defaults
+func getLLMSettings(fromFile LLMSettings, geminiAPIKey string) LLMSettings {
+	settings := LLMSettings{
+		Provider: strings.ToLower(strings.TrimSpace(lookup("LLM_PROVIDER", fromFile.Provider))),
+		Model:    strings.TrimSpace(lookup("LLM_MODEL", fromFile.Model)),
+		BaseURL:  strings.TrimSpace(lookup("LLM_BASE_URL", os.ExpandEnv(fromFile.BaseURL))),
+		APIKey:   strings.TrimSpace(lookup("LLM_API_KEY", os.ExpandEnv(fromFile.APIKey))),
+	}
+	if settings.Provider == "" {
+		settings.Provider = "gemini"
+	}
+	if _, ok := DefaultLLMModels[settings.Provider]; !ok {
+		fmt.Printf("Warning: unknown LLM provider %q, using gemini\n", settings.Provider)
+		settings = LLMSettings{Provider: "gemini"}
+	}
+	if settings.Model == "" {
+		settings.Model = DefaultLLMModels[settings.Provider]
+	}
+	if settings.BaseURL == "" {
+		settings.BaseURL = DefaultLLMBaseURLs[settings.Provider]
+	}
+	if settings.APIKey == "" && settings.Provider == "gemini" {
+		settings.APIKey = geminiAPIKey
+	}
+	settings.Batch = lookupBool("LLM_BATCH", fromFile.Batch)
+	settings.CacheDir = strings.TrimSpace(lookup("LLM_CACHE_DIR", orDefault(fromFile.CacheDir, DefaultLLMCacheDir)))
+	settings.CacheTTL = getCacheTTL(fromFile.CacheTTL)
+	return settings
+}
+
+// getCacheTTL returns how long model replies are reused, from LLM_CACHE_TTL
+// or the config file (default 12h)
+func getCacheTTL(fromFile time.Duration) time.Duration {
+	ttl := fromFile
+	if value := strings.TrimSpace(os.Getenv("LLM_CACHE_TTL")); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			fmt.Printf("Warning: ignoring invalid LLM_CACHE_TTL=%q\n", value)
+		} else {
+			ttl = parsed
+		}
+	}
+	if ttl <= 0 {
+		return DefaultLLMCacheTTL
+	}
+	return ttl
+}
+
+// getMarketCapSettings returns classification settings from the config file
+// and environment, filling in defaults
+func getMarketCapSettings(fromFile MarketCapSettings) MarketCapSettings {
+	settings := fromFile
+	settings.AMFIFile = strings.TrimSpace(lookup("AMFI_CLASSIFICATION_FILE", settings.AMFIFile))
+	settings.CacheFile = strings.TrimSpace(lookup("MARKET_CAP_CACHE_FILE", orDefault(settings.CacheFile, DefaultMarketCapCacheFile)))
+	if days := getEnvInt("MARKET_CAP_REFRESH_DAYS"); days > 0 {
+		settings.RefreshDays = days
+	}
+	if settings.RefreshDays <= 0 {
+		settings.RefreshDays = DefaultMarketCapRefreshDays
+	}
+	if settings.LargeCapMinCrore <= 0 {
+		settings.LargeCapMinCrore = DefaultLargeCapMinCrore
+	}
+	if settings.MidCapMinCrore <= 0 {
+		settings.MidCapMinCrore = DefaultMidCapMinCrore
+	}
+	return settings
+}
+
+// getMissingBarPolicy returns how to treat bars with missing prices (default "drop")
+func getMissingBarPolicy(fromFile string) string {
+	switch policy := strings.ToLower(strings.TrimSpace(lookup("MISSING_BAR_POLICY", fromFile))); policy {
+	case "", "drop":
+		return "drop"
+	case "ffill":
+		return "ffill"
+	default:
+		fmt.Printf("Warning: unknown MISSING_BAR_POLICY %q, using drop\n", policy)
+		return "drop"
+	}
+}
+
+// getParseMode returns the Telegram formatting mode (default "MarkdownV2")
+func getParseMode(value string) string {
+	switch mode := strings.ToLower(strings.TrimSpace(value)); mode {
+	case "", "markdownv2":
+		return "MarkdownV2"
+	case "html":
+		return "HTML"
+	default:
+		fmt.Printf("Warning: unknown Telegram parse mode %q, using MarkdownV2\n", value)
+		return "MarkdownV2"
+	}
+}
+
+// getHTTPMode returns whether outbound HTTP is live, recorded or replayed (default "live")
+func getHTTPMode() string {
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv("HTTP_MODE"))); mode {
+	case "", "live":
+		return "live"
+	case "record", "replay":
+		return mode
+	default:
+		fmt.Printf("Warning: unknown HTTP_MODE %q, using live\n", mode)
+		return "live"
+	}
+}
+
+// getInsightsMode returns who writes the recommendations (default "ai"):
+// the model, falling back to rules when it fails, or rules alone
+func getInsightsMode(fromFile string) string {
+	switch mode := strings.ToLower(strings.TrimSpace(lookup("INSIGHTS_MODE", fromFile))); mode {
+	case "", "ai":
+		return "ai"
+	case "rules":
+		return "rules"
+	default:
+		fmt.Printf("Warning: unknown INSIGHTS_MODE %q, using ai\n", mode)
+		return "ai"
+	}
+}
+
+// getMarketClosedPolicy returns what jobs do on weekends and exchange holidays (default "skip")
+func getMarketClosedPolicy(fromFile string) string {
+	switch policy := strings.ToLower(strings.TrimSpace(lookup("MARKET_CLOSED_POLICY", fromFile))); policy {
+	case "", "skip":
+		return "skip"
+	case "label":
+		return "label"
+	default:
+		fmt.Printf("Warning: unknown MARKET_CLOSED_POLICY %q, using skip\n", policy)
+		return "skip"
+	}
+}
+
+// getSchedules returns the cron expression for each job, applying file
+// values and then <JOB>_CRON overrides
+func getSchedules(fromFile map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for job, spec := range DefaultSchedules {
+		merged[job] = spec
+	}
+	for job, spec := range fromFile {
+		merged[job] = spec
+	}
+
+	schedules := make(map[string]string)
+	for job, spec := range merged {
+		spec = getEnvOrDefault(strings.ToUpper(job)+"_CRON", spec)
+		if strings.EqualFold(spec, "off") {
+			continue
+		}
+		schedules[job] = spec
+	}
+	return schedules
+}
+
+// lookup returns the environment variable if set, otherwise the config file value
+func lookup(key, fromFile string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fromFile
+}
+
+// lookupBool returns the boolean environment variable if set and valid,
+// otherwise the config file value
+func lookupBool(key string, fromFile bool) bool {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fromFile
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		fmt.Printf("Warning: ignoring invalid %s=%q\n", key, value)
+		return fromFile
+	}
+	return b
+}
+
+// getEnvOrDefault returns the trimmed environment variable, or fallback if unset
+func getEnvOrDefault(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// orDefaultList returns values, or fallback if values is empty
+func orDefaultList(values, fallback []string) []string {
+	if len(values) > 0 {
+		return values
+	}
+	return fallback
+}
+
+// orDefault returns value, or fallback if value is empty
+func orDefault(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getIndicatorEnv returns the indicator periods set through environment
+// variables, leaving the others unset
+func getIndicatorEnv() indicators.Settings {
+	return indicators.Settings{
+		MAShortPeriod:    getEnvInt("MA_SHORT_PERIOD"),
+		MALongPeriod:     getEnvInt("MA_LONG_PERIOD"),
+		RSIPeriod:        getEnvInt("RSI_PERIOD"),
+		EMAPeriod:        getEnvInt("EMA_PERIOD"),
+		MACDFastPeriod:   getEnvInt("MACD_FAST_PERIOD"),
+		MACDSlowPeriod:   getEnvInt("MACD_SLOW_PERIOD"),
+		MACDSignalPeriod: getEnvInt("MACD_SIGNAL_PERIOD"),
+		BollingerPeriod:  getEnvInt("BOLLINGER_PERIOD"),
+		BollingerStdDev:  getEnvFloat("BOLLINGER_STDDEV"),
+		ATRPeriod:        getEnvInt("ATR_PERIOD"),
+		StochasticK:      getEnvInt("STOCHASTIC_K_PERIOD"),
+		StochasticD:      getEnvInt("STOCHASTIC_D_PERIOD"),
+		ADXPeriod:        getEnvInt("ADX_PERIOD"),
+	}
+}
+
+// getEnvInt parses a positive integer environment variable, returning 0 if unset or invalid
+func getEnvInt(key string) int {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		fmt.Printf("Warning: ignoring invalid %s=%q\n", key, value)
+		return 0
+	}
+	return n
+}
+
+// getEnvFloat parses a positive number environment variable, returning 0 if unset or invalid
+func getEnvFloat(key string) float64 {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f <= 0 {
+		fmt.Printf("Warning: ignoring invalid %s=%q\n", key, value)
+		return 0
 	}
-	return DefaultStockList
+	return f
 }
 
 // getEnvVar retrieves an environment variable and ensures it's not empty
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestMain loads testdata/config.yaml, since the config file is only read
+// once per process, and clears the variables that would override it
+func TestMain(m *testing.M) {
+	for _, key := range []string{
+		"TELEGRAM_BOT_TOKEN", "TELEGRAM_CHAT_IDS", "TELEGRAM_PARSE_MODE", "GEMINI_API_KEY",
+		"LLM_PROVIDER", "LLM_MODEL", "LLM_BASE_URL", "LLM_API_KEY", "LLM_BATCH", "LLM_CACHE_DIR", "LLM_CACHE_TTL",
+		"INSIGHTS_MODE", "STOCK_LIST", "STOCK_CRON", "MARKETFALL_CRON", "SCORECARD_CRON",
+		"MA_SHORT_PERIOD", "MA_LONG_PERIOD", "RSI_PERIOD", "EMA_PERIOD", "BOLLINGER_STDDEV",
+	} {
+		os.Unsetenv(key)
+	}
+	os.Setenv("CONFIG_FILE", filepath.Join("testdata", "config.yaml"))
+	os.Exit(m.Run())
+}
+
+func TestGetConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		check func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "file values",
+			check: func(t *testing.T, cfg *Config) {
+				expect(t, "bot token", cfg.TelegramBotToken, "file-token")
+				expect(t, "chat IDs", cfg.TelegramChatIDs, []string{"111", "222"})
+				expect(t, "parse mode", cfg.TelegramParseMode, "HTML")
+				expect(t, "insights mode", cfg.InsightsMode, "rules")
+				expect(t, "LLM provider", cfg.LLM.Provider, "gemini")
+				expect(t, "LLM model", cfg.LLM.Model, "gemini-2.0-flash")
+				expect(t, "LLM API key", cfg.LLM.APIKey, "file-gemini-key")
+				expect(t, "LLM cache TTL", cfg.LLM.CacheTTL, 6*time.Hour)
+				expect(t, "stock schedule", cfg.Schedules["stock"], "0 17 * * 1-5")
+				expect(t, "marketfall schedule", cfg.Schedules["marketfall"], DefaultSchedules["marketfall"])
+			},
+		},
+		{
+			name: "environment over file",
+			env: map[string]string{
+				"TELEGRAM_BOT_TOKEN":  " env-token ",
+				"TELEGRAM_CHAT_IDS":   "9, 8,",
+				"TELEGRAM_PARSE_MODE": "MarkdownV2",
+				"INSIGHTS_MODE":       "ai",
+				"LLM_MODEL":           "gemini-2.5-pro",
+				"GEMINI_API_KEY":      "env-gemini-key",
+				"LLM_CACHE_TTL":       "1h",
+				"STOCK_CRON":          "off",
+				"SCORECARD_CRON":      "0 9 * * 6",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				expect(t, "bot token", cfg.TelegramBotToken, "env-token")
+				expect(t, "chat IDs", cfg.TelegramChatIDs, []string{"9", "8"})
+				expect(t, "parse mode", cfg.TelegramParseMode, "MarkdownV2")
+				expect(t, "insights mode", cfg.InsightsMode, "ai")
+				expect(t, "LLM model", cfg.LLM.Model, "gemini-2.5-pro")
+				expect(t, "LLM API key", cfg.LLM.APIKey, "env-gemini-key")
+				expect(t, "LLM cache TTL", cfg.LLM.CacheTTL, time.Hour)
+				if _, ok := cfg.Schedules["stock"]; ok {
+					t.Errorf("stock schedule is set, want it turned off")
+				}
+				expect(t, "scorecard schedule", cfg.Schedules["scorecard"], "0 9 * * 6")
+			},
+		},
+		{
+			name: "invalid environment ignored",
+			env: map[string]string{
+				"TELEGRAM_PARSE_MODE": "markdown",
+				"LLM_CACHE_TTL":       "soon",
+				"LLM_BATCH":           "maybe",
+				"INSIGHTS_MODE":       "magic",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				expect(t, "parse mode", cfg.TelegramParseMode, "MarkdownV2")
+				expect(t, "LLM cache TTL", cfg.LLM.CacheTTL, 6*time.Hour)
+				expect(t, "LLM batch", cfg.LLM.Batch, false)
+				expect(t, "insights mode", cfg.InsightsMode, "ai")
+			},
+		},
+		{
+			name: "LLM API key over Gemini key",
+			env:  map[string]string{"LLM_API_KEY": "llm-key", "GEMINI_API_KEY": "env-gemini-key"},
+			check: func(t *testing.T, cfg *Config) {
+				expect(t, "LLM API key", cfg.LLM.APIKey, "llm-key")
+			},
+		},
+		{
+			name: "provider defaults",
+			env:  map[string]string{"LLM_PROVIDER": "OpenAI"},
+			check: func(t *testing.T, cfg *Config) {
+				expect(t, "LLM provider", cfg.LLM.Provider, "openai")
+				expect(t, "LLM base URL", cfg.LLM.BaseURL, DefaultLLMBaseURLs["openai"])
+				expect(t, "LLM API key", cfg.LLM.APIKey, "") // The Gemini key is not sent to other providers
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			tt.check(t, GetConfig())
+		})
+	}
+}
+
+// TestIndicatorPrecedence checks the layering of indicator periods:
+// defaults, then the file's global settings, then each watchlist's own,
+// with environment variables over all of them
+func TestIndicatorPrecedence(t *testing.T) {
+	tests := []struct {
+		name                      string
+		env                       map[string]string
+		global, large, banks      int // RSI periods
+		largeEMA, banksEMA        int
+		largeMAShort, largeMALong int
+		bollinger                 float64
+	}{
+		{
+			name:   "file",
+			global: 21, large: 9, banks: 21,
+			largeEMA: 50, banksEMA: 20,
+			largeMAShort: 10, largeMALong: 20,
+			bollinger: 2,
+		},
+		{
+			name:   "environment",
+			env:    map[string]string{"RSI_PERIOD": "30", "MA_LONG_PERIOD": "50", "BOLLINGER_STDDEV": "2.5"},
+			global: 30, large: 30, banks: 30,
+			largeEMA: 50, banksEMA: 20,
+			largeMAShort: 10, largeMALong: 50,
+			bollinger: 2.5,
+		},
+		{
+			name:   "invalid environment",
+			env:    map[string]string{"RSI_PERIOD": "-3", "EMA_PERIOD": "ten"},
+			global: 21, large: 9, banks: 21,
+			largeEMA: 50, banksEMA: 20,
+			largeMAShort: 10, largeMALong: 20,
+			bollinger: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			cfg := GetConfig()
+			large, banks := watchlist(t, cfg, "Large Caps"), watchlist(t, cfg, "Banks")
+
+			expect(t, "global RSI", cfg.Indicators.RSIPeriod, tt.global)
+			expect(t, "Large Caps RSI", large.Indicators.RSIPeriod, tt.large)
+			expect(t, "Banks RSI", banks.Indicators.RSIPeriod, tt.banks)
+			expect(t, "Large Caps EMA", large.Indicators.EMAPeriod, tt.largeEMA)
+			expect(t, "Banks EMA", banks.Indicators.EMAPeriod, tt.banksEMA)
+			expect(t, "Large Caps short MA", large.Indicators.MAShortPeriod, tt.largeMAShort)
+			expect(t, "Large Caps long MA", large.Indicators.MALongPeriod, tt.largeMALong)
+			expect(t, "Large Caps Bollinger width", large.Indicators.BollingerStdDev, tt.bollinger)
+		})
+	}
+}
+
+func TestWatchlistDefaults(t *testing.T) {
+	cfg := GetConfig()
+	large, banks := watchlist(t, cfg, "Large Caps"), watchlist(t, cfg, "Banks")
+
+	expect(t, "Large Caps chats", large.ChatIDs, []string{"333"})
+	expect(t, "Banks chats", banks.ChatIDs, []string{"111", "222"})
+	expect(t, "Large Caps notify", large.Notify, DefaultNotify)
+	expect(t, "Banks notify", banks.Notify, []string{"slack"})
+	expect(t, "Large Caps symbols", large.Symbols, []WatchlistSymbol{{Symbol: "RELIANCE.NS"}, {Symbol: "TCS.NS", Name: "TCS"}})
+}
+
+func TestWatchlistsFromStockList(t *testing.T) {
+	base := []Watchlist{
+		{Name: "Large Caps", Symbols: []WatchlistSymbol{{Symbol: "RELIANCE.NS"}, {Symbol: "TCS.NS", Name: "TCS"}}},
+		{Name: "Banks", Symbols: []WatchlistSymbol{{Symbol: "HDFCBANK.NS"}}},
+	}
+
+	tests := []struct {
+		name       string
+		stockList  string
+		otherIndex int
+		want       map[string][]string // Watchlist name -> symbols
+	}{
+		{
+			name:       "keeps known symbols in their watchlist",
+			stockList:  "TCS.NS, HDFCBANK.NS",
+			otherIndex: -1,
+			want:       map[string][]string{"Large Caps": {"TCS.NS"}, "Banks": {"HDFCBANK.NS"}},
+		},
+		{
+			name:       "unknown symbols go to Other Stocks",
+			stockList:  "WIPRO.NS,,TCS.NS",
+			otherIndex: -1,
+			want:       map[string][]string{"Large Caps": {"TCS.NS"}, OtherWatchlistName: {"WIPRO.NS"}},
+		},
+		{
+			name:       "unknown symbols go to the default watchlist",
+			stockList:  "WIPRO.NS,HDFCBANK.NS",
+			otherIndex: 0,
+			want:       map[string][]string{"Large Caps": {"WIPRO.NS"}, "Banks": {"HDFCBANK.NS"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string][]string)
+			for _, w := range watchlistsFromStockList(tt.stockList, base, tt.otherIndex) {
+				got[w.Name] = w.Tickers()
+			}
+			expect(t, "watchlists", got, tt.want)
+		})
+	}
+
+	// The display name comes from the watchlist that already had the symbol
+	result := watchlistsFromStockList("TCS.NS", base, -1)
+	expect(t, "TCS display name", result[0].Symbols[0].DisplayName(), "TCS")
+}
+
+// watchlist returns the watchlist with the given name
+func watchlist(t *testing.T, cfg *Config, name string) Watchlist {
+	t.Helper()
+	for _, w := range cfg.Watchlists {
+		if w.Name == name {
+			return w
+		}
+	}
+	t.Fatalf("no watchlist named %q", name)
+	return Watchlist{}
+}
+
+func expect(t *testing.T, what string, got, want interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s = %v, want %v", what, got, want)
+	}
+}
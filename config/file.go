@@ -0,0 +1,79 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"go-stock/indicators"
+)
+
+// DefaultConfigFile is read when CONFIG_FILE is not set, if it exists
+const DefaultConfigFile = "config.yaml"
+
+// fileConfig is the layout of the YAML config file.
+// Every value can still be overridden by its environment variable.
+type fileConfig struct {
+	Telegram struct {
+		BotToken  string   `yaml:"bot_token"`
+		ChatIDs   []string `yaml:"chat_ids"`
+		ParseMode string   `yaml:"parse_mode"`
+	} `yaml:"telegram"`
+	GeminiAPIKey       string                    `yaml:"gemini_api_key"`
+	LLM                LLMSettings               `yaml:"llm"`
+	InsightsMode       string                    `yaml:"insights_mode"`
+	PromptDir          string                    `yaml:"prompt_dir"`
+	Indicators         indicators.Settings       `yaml:"indicators"`
+	MissingBarPolicy   string                    `yaml:"missing_bar_policy"`
+	Timezone           string                    `yaml:"timezone"`
+	Schedules          map[string]string         `yaml:"schedules"`
+	MarketHolidaysFile string                    `yaml:"market_holidays_file"`
+	MarketClosedPolicy string                    `yaml:"market_closed_policy"`
+	MarketCap          MarketCapSettings         `yaml:"market_cap"`
+	Notifiers          map[string]NotifierConfig `yaml:"notifiers"`
+	MarketFall         struct {
+		Notify []string `yaml:"notify"`
+	} `yaml:"marketfall"`
+	Scorecard struct {
+		Notify []string `yaml:"notify"`
+	} `yaml:"scorecard"`
+	Watchlists         []Watchlist `yaml:"watchlists"`
+	ChatWatchlistsFile string      `yaml:"chat_watchlists_file"`
+	StoreFile          string      `yaml:"store_file"`
+}
+
+var (
+	fileOnce   sync.Once
+	loadedFile fileConfig
+)
+
+// loadFile reads the config file once per process. A missing default file is
+// fine, but an explicitly named or malformed file is a fatal error.
+func loadFile() fileConfig {
+	fileOnce.Do(func() {
+		path := os.Getenv("CONFIG_FILE")
+		explicit := path != ""
+		if !explicit {
+			path = DefaultConfigFile
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if !explicit && os.IsNotExist(err) {
+				return
+			}
+			panic(fmt.Sprintf("Failed to read config file %s: %v", path, err))
+		}
+
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true) // Catch typos in setting names
+		if err := decoder.Decode(&loadedFile); err != nil && err != io.EOF {
+			panic(fmt.Sprintf("Invalid config file %s: %v", path, err))
+		}
+	})
+	return loadedFile
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"go-stock/indicators"
+)
+
+// Watchlist is a named group of symbols reported together
+type Watchlist struct {
+	Name       string              `yaml:"name"`
+	Category   string              `yaml:"category"` // e.g. "large-cap", shown for information only
+	Symbols    []WatchlistSymbol   `yaml:"symbols"`
+	Indicators indicators.Settings `yaml:"indicators"` // Overrides the global indicator settings
+	ChatIDs    []string            `yaml:"chats"`      // Defaults to TELEGRAM_CHAT_IDS
+	Notify     []string            `yaml:"notify"`     // Notifier names, defaults to ["telegram"]
+
+	// GroupByMarketCap splits the report into large, mid and small cap
+	// sections using each symbol's computed SEBI/AMFI class
+	GroupByMarketCap bool `yaml:"group_by_market_cap"`
+
+	// Charts attaches a candlestick chart per stock (default true)
+	Charts *bool `yaml:"charts"`
+}
+
+// ChartsEnabled reports whether charts are attached to the watchlist's reports
+func (w Watchlist) ChartsEnabled() bool {
+	return w.Charts == nil || *w.Charts
+}
+
+// WatchlistSymbol is a ticker with an optional display name.
+// In YAML it can be written as a plain string or as {symbol, name}.
+type WatchlistSymbol struct {
+	Symbol string `yaml:"symbol"`
+	Name   string `yaml:"name"`
+}
+
+// UnmarshalYAML accepts both "RELIANCE.NS" and {symbol: RELIANCE.NS, name: Reliance}
+func (s *WatchlistSymbol) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		s.Symbol = node.Value
+		return nil
+	}
+	type plain WatchlistSymbol
+	return node.Decode((*plain)(s))
+}
+
+// DisplayName returns the configured name, or the ticker without its exchange suffix
+func (s WatchlistSymbol) DisplayName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(s.Symbol, ".NS"), ".BO")
+}
+
+// Tickers returns the watchlist's symbols as plain tickers
+func (w Watchlist) Tickers() []string {
+	tickers := make([]string, len(w.Symbols))
+	for i, s := range w.Symbols {
+		tickers[i] = s.Symbol
+	}
+	return tickers
+}
+
+// DefaultWatchlists are used when neither a config file nor STOCK_LIST
+// defines any. Symbols are grouped by their computed market-cap class.
+var DefaultWatchlists = []Watchlist{
+	{
+		Name:             "Stocks",
+		GroupByMarketCap: true,
+		Symbols: []WatchlistSymbol{
+			{Symbol: "RELIANCE.NS", Name: "Reliance Industries"},
+			{Symbol: "TCS.NS", Name: "Tata Consultancy Services"},
+			{Symbol: "HDFCBANK.NS", Name: "HDFC Bank"},
+			{Symbol: "INFY.NS", Name: "Infosys"},
+			{Symbol: "ICICIBANK.NS", Name: "ICICI Bank"},
+			{Symbol: "TATAMOTORS.NS", Name: "Tata Motors"},
+			{Symbol: "ADANIENT.NS", Name: "Adani Enterprises"},
+			{Symbol: "BAJFINANCE.NS", Name: "Bajaj Finance"},
+			{Symbol: "TITAN.NS", Name: "Titan Company"},
+			{Symbol: "MARICO.NS", Name: "Marico"},
+			{Symbol: "JUBLFOOD.NS", Name: "Jubilant FoodWorks"},
+			{Symbol: "FORTIS.NS", Name: "Fortis Healthcare"},
+			{Symbol: "KALYANKJIL.NS", Name: "Kalyan Jewellers"},
+			{Symbol: "SUPREMEIND.NS", Name: "Supreme Industries"},
+			{Symbol: "VBL.NS", Name: "Varun Beverages"},
+		},
+	},
+}
+
+// OtherWatchlistName holds STOCK_LIST symbols that are not in any configured watchlist
+const OtherWatchlistName = "Other Stocks"
+
+// watchlistsFromStockList keeps each symbol of a comma-separated list in the
+// base watchlist that already contains it. The rest go to base[otherIndex],
+// or to a new market-cap grouped "Other Stocks" watchlist if otherIndex < 0.
+func watchlistsFromStockList(stockList string, base []Watchlist, otherIndex int) []Watchlist {
+	result := make([]Watchlist, len(base))
+	index := make(map[string]WatchlistSymbol)
+	owner := make(map[string]int)
+	for i, w := range base {
+		result[i] = w
+		result[i].Symbols = nil
+		for _, s := range w.Symbols {
+			index[s.Symbol] = s
+			owner[s.Symbol] = i
+		}
+	}
+
+	other := Watchlist{Name: OtherWatchlistName, GroupByMarketCap: true}
+	for _, symbol := range strings.Split(stockList, ",") {
+		symbol = strings.TrimSpace(symbol)
+		if symbol == "" {
+			continue
+		}
+		if i, ok := owner[symbol]; ok {
+			result[i].Symbols = append(result[i].Symbols, index[symbol])
+		} else if otherIndex >= 0 {
+			result[otherIndex].Symbols = append(result[otherIndex].Symbols, WatchlistSymbol{Symbol: symbol})
+		} else {
+			other.Symbols = append(other.Symbols, WatchlistSymbol{Symbol: symbol})
+		}
+	}
+	result = append(result, other)
+
+	// Drop watchlists left empty by the override
+	var nonEmpty []Watchlist
+	for _, w := range result {
+		if len(w.Symbols) > 0 {
+			nonEmpty = append(nonEmpty, w)
+		}
+	}
+	return nonEmpty
+}
@@ -0,0 +1,40 @@
+// Package fileutil writes and locks the data files shared between runs and
+// between processes such as the daemon and the bot.
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFile replaces the file at path with data, creating its directory if
+// needed. The data goes to a temporary file in the same directory that is
+// then renamed over path, so a crash or a concurrent writer never leaves
+// it half-written.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package fileutil
+
+// Lock is a no-op where flock is unavailable; writers in one process are
+// still serialised by their callers, and WriteFile keeps every write whole
+func Lock(path string) (unlock func(), err error) {
+	return func() {}, nil
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package fileutil
+
+import (
+	"os"
+	"syscall"
+)
+
+// Lock takes an exclusive lock on path+".lock", waiting for any other
+// process holding it, and returns the function that releases it
+func Lock(path string) (unlock func(), err error) {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -5,6 +5,12 @@ go 1.21
 require (
 	github.com/go-resty/resty/v2 v2.11.0
 	github.com/robfig/cron/v3 v3.0.1
+	go.etcd.io/bbolt v1.3.10
+	golang.org/x/image v0.18.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require golang.org/x/net v0.17.0 // indirect
+require (
+	golang.org/x/net v0.17.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+)
@@ -0,0 +1,73 @@
+package indicators
+
+// SMA returns the simple moving average of the last `period` values.
+// If fewer values are available, the average of all of them is returned.
+func SMA(values []float64, period int) float64 {
+	if len(values) == 0 || period <= 0 {
+		return 0
+	}
+	if period > len(values) {
+		period = len(values)
+	}
+
+	var sum float64
+	for _, v := range values[len(values)-period:] {
+		sum += v
+	}
+	return sum / float64(period)
+}
+
+// RSI returns the relative strength index using Wilder's smoothing, the
+// same method used by TradingView and Zerodha Kite. The first average gain
+// and loss are seeded with a simple mean over `period` changes and then
+// smoothed across the remaining history, so more history gives values
+// closer to those platforms. Returns 50 (neutral) when there is not enough data.
+func RSI(closes []float64, period int) float64 {
+	if period <= 0 || len(closes) < period+1 {
+		return 50
+	}
+
+	var avgGain, avgLoss float64
+	for i := 1; i <= period; i++ {
+		gain, loss := splitChange(closes[i] - closes[i-1])
+		avgGain += gain
+		avgLoss += loss
+	}
+	avgGain /= float64(period)
+	avgLoss /= float64(period)
+
+	for i := period + 1; i < len(closes); i++ {
+		gain, loss := splitChange(closes[i] - closes[i-1])
+		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+	}
+
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50 // Flat prices
+		}
+		return 100 // Perfect uptrend
+	}
+	if avgGain == 0 {
+		return 0 // Perfect downtrend
+	}
+
+	rs := avgGain / avgLoss
+	return 100 - (100 / (1 + rs))
+}
+
+// splitChange returns a price change as a (gain, loss) pair of non-negative values
+func splitChange(change float64) (float64, float64) {
+	if change > 0 {
+		return change, 0
+	}
+	return 0, -change
+}
+
+// PercentChange returns the change from `from` to `to` in percent
+func PercentChange(from, to float64) float64 {
+	if from == 0 {
+		return 0
+	}
+	return ((to - from) / from) * 100
+}
@@ -0,0 +1,59 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+// stockCharts is the worked RSI example from StockCharts' ChartSchool. Its
+// table rounds every average to two places, so it lists 70.53 where the
+// unrounded Wilder RSI(14) is 70.46
+var stockCharts = []float64{
+	44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+	45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+}
+
+func TestRSI(t *testing.T) {
+	tests := []struct {
+		name   string
+		closes []float64
+		period int
+		want   float64
+	}{
+		{"seed average", stockCharts[:15], 14, 70.46},
+		{"one smoothed step", stockCharts[:16], 14, 66.25},
+		{"smoothed history", stockCharts, 14, 57.92},
+		{"not enough data", stockCharts[:14], 14, 50},
+		{"zero period", stockCharts, 0, 50},
+		{"flat", []float64{10, 10, 10, 10}, 3, 50},
+		{"only gains", []float64{1, 2, 3, 4}, 3, 100},
+		{"only losses", []float64{4, 3, 2, 1}, 3, 0},
+		// Gains of 2 and 1 against a loss of 1 seed 1 and 1/3, then the
+		// final loss of 3 smooths them to 2/3 and 11/9
+		{"hand worked", []float64{10, 12, 11, 12, 9}, 3, 100 - 100/(1+(2.0/3)/(11.0/9))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RSI(tt.closes, tt.period); math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("RSI() = %.4f, want %.4f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercentChange(t *testing.T) {
+	tests := []struct {
+		from, to, want float64
+	}{
+		{100, 110, 10},
+		{200, 150, -25},
+		{0, 50, 0},
+	}
+
+	for _, tt := range tests {
+		if got := PercentChange(tt.from, tt.to); got != tt.want {
+			t.Errorf("PercentChange(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package indicators
+
+import (
+	"sort"
+	"time"
+)
+
+// Bar is a single OHLCV price bar
+type Bar struct {
+	Time   time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume int64
+}
+
+// Series is a list of bars ordered oldest-first.
+// All indicator functions in this package rely on that ordering.
+type Series []Bar
+
+// NewSeries copies bars into a Series sorted oldest-first
+func NewSeries(bars []Bar) Series {
+	series := make(Series, len(bars))
+	copy(series, bars)
+	sort.SliceStable(series, func(i, j int) bool {
+		return series[i].Time.Before(series[j].Time)
+	})
+	return series
+}
+
+// Closes returns the closing prices, oldest-first
+func (s Series) Closes() []float64 {
+	closes := make([]float64, len(s))
+	for i, bar := range s {
+		closes[i] = bar.Close
+	}
+	return closes
+}
+
+// Last returns the most recent bar, or a zero Bar if the series is empty
+func (s Series) Last() Bar {
+	if len(s) == 0 {
+		return Bar{}
+	}
+	return s[len(s)-1]
+}
+
+// Tail returns the most recent n bars (or the whole series if shorter)
+func (s Series) Tail(n int) Series {
+	if n >= len(s) {
+		return s
+	}
+	return s[len(s)-n:]
+}
+
+// AverageVolume returns the mean volume across the series
+func (s Series) AverageVolume() int64 {
+	if len(s) == 0 {
+		return 0
+	}
+	var total int64
+	for _, bar := range s {
+		total += bar.Volume
+	}
+	return total / int64(len(s))
+}
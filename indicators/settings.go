@@ -0,0 +1,127 @@
+package indicators
+
+// Settings holds the lookback periods used when computing indicators
+type Settings struct {
+	MAShortPeriod    int     `yaml:"ma_short_period"`    // Short moving average, e.g. 5 days
+	MALongPeriod     int     `yaml:"ma_long_period"`     // Long moving average, e.g. 20 days
+	RSIPeriod        int     `yaml:"rsi_period"`         // RSI period, e.g. 14 days
+	EMAPeriod        int     `yaml:"ema_period"`         // Exponential moving average, e.g. 20 days
+	MACDFastPeriod   int     `yaml:"macd_fast_period"`   // MACD fast EMA, e.g. 12 days
+	MACDSlowPeriod   int     `yaml:"macd_slow_period"`   // MACD slow EMA, e.g. 26 days
+	MACDSignalPeriod int     `yaml:"macd_signal_period"` // MACD signal EMA, e.g. 9 days
+	BollingerPeriod  int     `yaml:"bollinger_period"`   // Bollinger Bands period, e.g. 20 days
+	BollingerStdDev  float64 `yaml:"bollinger_stddev"`   // Bollinger Bands width in standard deviations, e.g. 2
+	ATRPeriod        int     `yaml:"atr_period"`         // Average True Range period, e.g. 14 days
+	StochasticK      int     `yaml:"stochastic_k"`       // Stochastic %K lookback, e.g. 14 days
+	StochasticD      int     `yaml:"stochastic_d"`       // Stochastic %D smoothing, e.g. 3 days
+	ADXPeriod        int     `yaml:"adx_period"`         // Average Directional Index period, e.g. 14 days
+}
+
+// wilderWarmup is how many multiples of a Wilder period to fetch so the
+// smoothed average converges with what charting platforms display
+const wilderWarmup = 10
+
+// emaWarmup is how many multiples of an EMA period to fetch before the
+// seed value stops influencing the result
+const emaWarmup = 4
+
+// DefaultSettings returns the conventional periods used by most charting platforms
+func DefaultSettings() Settings {
+	return Settings{
+		MAShortPeriod:    5,
+		MALongPeriod:     20,
+		RSIPeriod:        14,
+		EMAPeriod:        20,
+		MACDFastPeriod:   12,
+		MACDSlowPeriod:   26,
+		MACDSignalPeriod: 9,
+		BollingerPeriod:  20,
+		BollingerStdDev:  2,
+		ATRPeriod:        14,
+		StochasticK:      14,
+		StochasticD:      3,
+		ADXPeriod:        14,
+	}
+}
+
+// WithDefaults fills any unset period from DefaultSettings
+func (s Settings) WithDefaults() Settings {
+	defaults := DefaultSettings()
+	fill := func(value *int, fallback int) {
+		if *value <= 0 {
+			*value = fallback
+		}
+	}
+
+	fill(&s.MAShortPeriod, defaults.MAShortPeriod)
+	fill(&s.MALongPeriod, defaults.MALongPeriod)
+	fill(&s.RSIPeriod, defaults.RSIPeriod)
+	fill(&s.EMAPeriod, defaults.EMAPeriod)
+	fill(&s.MACDFastPeriod, defaults.MACDFastPeriod)
+	fill(&s.MACDSlowPeriod, defaults.MACDSlowPeriod)
+	fill(&s.MACDSignalPeriod, defaults.MACDSignalPeriod)
+	fill(&s.BollingerPeriod, defaults.BollingerPeriod)
+	fill(&s.ATRPeriod, defaults.ATRPeriod)
+	fill(&s.StochasticK, defaults.StochasticK)
+	fill(&s.StochasticD, defaults.StochasticD)
+	fill(&s.ADXPeriod, defaults.ADXPeriod)
+	if s.BollingerStdDev <= 0 {
+		s.BollingerStdDev = defaults.BollingerStdDev
+	}
+	return s
+}
+
+// Override returns a copy of s with every period that is set in o replacing its own
+func (s Settings) Override(o Settings) Settings {
+	replace := func(value *int, override int) {
+		if override > 0 {
+			*value = override
+		}
+	}
+
+	replace(&s.MAShortPeriod, o.MAShortPeriod)
+	replace(&s.MALongPeriod, o.MALongPeriod)
+	replace(&s.RSIPeriod, o.RSIPeriod)
+	replace(&s.EMAPeriod, o.EMAPeriod)
+	replace(&s.MACDFastPeriod, o.MACDFastPeriod)
+	replace(&s.MACDSlowPeriod, o.MACDSlowPeriod)
+	replace(&s.MACDSignalPeriod, o.MACDSignalPeriod)
+	replace(&s.BollingerPeriod, o.BollingerPeriod)
+	replace(&s.ATRPeriod, o.ATRPeriod)
+	replace(&s.StochasticK, o.StochasticK)
+	replace(&s.StochasticD, o.StochasticD)
+	replace(&s.ADXPeriod, o.ADXPeriod)
+	if o.BollingerStdDev > 0 {
+		s.BollingerStdDev = o.BollingerStdDev
+	}
+	return s
+}
+
+// RequiredBars returns how many daily bars are needed to compute every indicator
+func (s Settings) RequiredBars() int {
+	candidates := []int{
+		s.MAShortPeriod,
+		s.MALongPeriod,
+		s.RSIPeriod*wilderWarmup + 1,
+		s.EMAPeriod * emaWarmup,
+		(s.MACDSlowPeriod + s.MACDSignalPeriod) * emaWarmup,
+		s.BollingerPeriod,
+		s.ATRPeriod*wilderWarmup + 1,
+		s.StochasticK + s.StochasticD,
+		s.ADXPeriod*wilderWarmup + 1,
+	}
+
+	bars := 0
+	for _, n := range candidates {
+		if n > bars {
+			bars = n
+		}
+	}
+	return bars
+}
+
+// LookbackDays converts RequiredBars into calendar days, allowing for
+// weekends and exchange holidays
+func (s Settings) LookbackDays() int {
+	return s.RequiredBars()*7/5 + 15
+}
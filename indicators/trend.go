@@ -0,0 +1,131 @@
+package indicators
+
+import "math"
+
+// EMASeries returns the exponential moving average for every value from
+// index period-1 onwards, seeded with the simple average of the first
+// `period` values. Returns nil when there is not enough data.
+func EMASeries(values []float64, period int) []float64 {
+	if period <= 0 || len(values) < period {
+		return nil
+	}
+
+	var seed float64
+	for _, v := range values[:period] {
+		seed += v
+	}
+	seed /= float64(period)
+
+	multiplier := 2 / float64(period+1)
+	ema := make([]float64, 0, len(values)-period+1)
+	ema = append(ema, seed)
+	for _, v := range values[period:] {
+		prev := ema[len(ema)-1]
+		ema = append(ema, (v-prev)*multiplier+prev)
+	}
+	return ema
+}
+
+// EMA returns the latest exponential moving average, or 0 when there is not enough data
+func EMA(values []float64, period int) float64 {
+	ema := EMASeries(values, period)
+	if len(ema) == 0 {
+		return 0
+	}
+	return ema[len(ema)-1]
+}
+
+// MACD returns the MACD line, signal line and histogram.
+// All three are 0 when there is not enough data.
+func MACD(closes []float64, fast, slow, signal int) (float64, float64, float64) {
+	fastEMA := EMASeries(closes, fast)
+	slowEMA := EMASeries(closes, slow)
+	if len(fastEMA) == 0 || len(slowEMA) == 0 {
+		return 0, 0, 0
+	}
+
+	// Align the two series on their most recent values
+	offset := len(fastEMA) - len(slowEMA)
+	if offset < 0 {
+		return 0, 0, 0
+	}
+	line := make([]float64, len(slowEMA))
+	for i := range slowEMA {
+		line[i] = fastEMA[i+offset] - slowEMA[i]
+	}
+
+	signalEMA := EMASeries(line, signal)
+	if len(signalEMA) == 0 {
+		return line[len(line)-1], 0, 0
+	}
+
+	macd := line[len(line)-1]
+	signalValue := signalEMA[len(signalEMA)-1]
+	return macd, signalValue, macd - signalValue
+}
+
+// ADX returns the Average Directional Index along with the +DI and -DI
+// lines, using Wilder's smoothing. All three are 0 when there is not enough data.
+func ADX(series Series, period int) (float64, float64, float64) {
+	if period <= 0 || len(series) < 2*period+1 {
+		return 0, 0, 0
+	}
+
+	var smoothedTR, smoothedPlusDM, smoothedMinusDM float64
+	var plusDI, minusDI, adx float64
+	var dxSum float64
+
+	for i := 1; i < len(series); i++ {
+		tr := trueRange(series[i], series[i-1])
+		upMove := series[i].High - series[i-1].High
+		downMove := series[i-1].Low - series[i].Low
+
+		var plusDM, minusDM float64
+		if upMove > downMove && upMove > 0 {
+			plusDM = upMove
+		}
+		if downMove > upMove && downMove > 0 {
+			minusDM = downMove
+		}
+
+		if i <= period {
+			// Accumulate the initial sums
+			smoothedTR += tr
+			smoothedPlusDM += plusDM
+			smoothedMinusDM += minusDM
+			if i < period {
+				continue
+			}
+		} else {
+			smoothedTR = smoothedTR - smoothedTR/float64(period) + tr
+			smoothedPlusDM = smoothedPlusDM - smoothedPlusDM/float64(period) + plusDM
+			smoothedMinusDM = smoothedMinusDM - smoothedMinusDM/float64(period) + minusDM
+		}
+
+		if smoothedTR == 0 {
+			plusDI, minusDI = 0, 0
+		} else {
+			plusDI = 100 * smoothedPlusDM / smoothedTR
+			minusDI = 100 * smoothedMinusDM / smoothedTR
+		}
+
+		var dx float64
+		if diSum := plusDI + minusDI; diSum > 0 {
+			dx = 100 * math.Abs(plusDI-minusDI) / diSum
+		}
+
+		// The first ADX is the mean of `period` DX values, then Wilder-smoothed
+		dxIndex := i - period
+		switch {
+		case dxIndex < period-1:
+			dxSum += dx
+		case dxIndex == period-1:
+			dxSum += dx
+			adx = dxSum / float64(period)
+		default:
+			adx = (adx*float64(period-1) + dx) / float64(period)
+		}
+	}
+
+	return adx, plusDI, minusDI
+}
@@ -0,0 +1,105 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// ramp returns n closes rising by 1 a day from 1
+func ramp(n int) []float64 {
+	closes := make([]float64, n)
+	for i := range closes {
+		closes[i] = float64(i + 1)
+	}
+	return closes
+}
+
+// steps returns n bars whose close moves by `step` a day with a range of 2
+func steps(n int, step float64) Series {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	series := make(Series, n)
+	for i := range series {
+		close := 100 + step*float64(i)
+		series[i] = Bar{Time: start.AddDate(0, 0, i), Open: close, High: close + 1, Low: close - 1, Close: close}
+	}
+	return series
+}
+
+func TestMACD(t *testing.T) {
+	// An EMA seeded with the simple average trails a steady ramp by
+	// (period-1)/2, so the MACD line settles at (slow-fast)/2
+	tests := []struct {
+		name                   string
+		closes                 []float64
+		fast, slow, signal     int
+		line, signalLine, hist float64
+	}{
+		{"ramp", ramp(40), 12, 26, 9, 7, 7, 0},
+		{"flat", []float64{5, 5, 5, 5, 5, 5, 5, 5}, 2, 4, 3, 0, 0, 0},
+		{"no signal yet", ramp(27), 12, 26, 9, 7, 0, 0},
+		{"not enough data", ramp(25), 12, 26, 9, 0, 0, 0},
+		{"fast slower than slow", ramp(40), 26, 12, 9, 0, 0, 0},
+		// EMA(2) runs 7, 19/3, 73/9 against EMA(3)'s 6, 6, 7.5, so the line
+		// is 1, 1/3, 11/18 and its EMA(2) signal 2/3 then 17/27
+		{"hand worked", []float64{4, 6, 8, 6, 9}, 2, 3, 2, 11.0 / 18, 17.0 / 27, 11.0/18 - 17.0/27},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, signal, hist := MACD(tt.closes, tt.fast, tt.slow, tt.signal)
+			if !near(line, tt.line) || !near(signal, tt.signalLine) || !near(hist, tt.hist) {
+				t.Errorf("MACD() = %.4f / %.4f / %.4f, want %.4f / %.4f / %.4f",
+					line, signal, hist, tt.line, tt.signalLine, tt.hist)
+			}
+		})
+	}
+}
+
+func TestADX(t *testing.T) {
+	// Each bar's true range is 2, so a steady move of 1 a day puts the
+	// directional line in its favour at 50 and the ADX at 100
+	tests := []struct {
+		name                 string
+		series               Series
+		period               int
+		adx, plusDI, minusDI float64
+	}{
+		{"uptrend", steps(30, 1), 14, 100, 50, 0},
+		{"downtrend", steps(30, -1), 14, 100, 0, 50},
+		{"flat", steps(30, 0), 14, 0, 0, 0},
+		{"first value", steps(29, 1), 14, 100, 50, 0},
+		{"not enough data", steps(28, 1), 14, 0, 0, 0},
+		{"zero period", steps(30, 1), 0, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adx, plusDI, minusDI := ADX(tt.series, tt.period)
+			if !near(adx, tt.adx) || !near(plusDI, tt.plusDI) || !near(minusDI, tt.minusDI) {
+				t.Errorf("ADX() = %.4f / %.4f / %.4f, want %.4f / %.4f / %.4f",
+					adx, plusDI, minusDI, tt.adx, tt.plusDI, tt.minusDI)
+			}
+		})
+	}
+}
+
+// TestADXTrendReversal checks that the ADX falls once a trend turns, since
+// a reversal shows up as -DI catching +DI rather than as a new high
+func TestADXTrendReversal(t *testing.T) {
+	series := steps(30, 1)
+	last := series[len(series)-1]
+	for i := 1; i <= 10; i++ {
+		close := last.Close - float64(i)
+		series = append(series, Bar{Time: last.Time.AddDate(0, 0, i), Open: close, High: close + 1, Low: close - 1, Close: close})
+	}
+
+	adx, plusDI, minusDI := ADX(series, 14)
+	if adx >= 100 || minusDI <= plusDI {
+		t.Errorf("ADX() = %.2f / %.2f / %.2f, want the ADX below 100 and -DI above +DI", adx, plusDI, minusDI)
+	}
+}
+
+func near(got, want float64) bool {
+	return math.Abs(got-want) < 1e-6
+}
@@ -0,0 +1,80 @@
+package indicators
+
+import "math"
+
+// BollingerBands returns the upper, middle and lower bands and %B for the
+// last `period` closes. %B is 0 at the lower band and 1 at the upper band.
+// All values are 0 when there is not enough data.
+func BollingerBands(closes []float64, period int, stdDevs float64) (float64, float64, float64, float64) {
+	if period <= 0 || len(closes) < period {
+		return 0, 0, 0, 0
+	}
+
+	window := closes[len(closes)-period:]
+	middle := SMA(window, period)
+
+	var variance float64
+	for _, v := range window {
+		variance += (v - middle) * (v - middle)
+	}
+	stdDev := math.Sqrt(variance / float64(period))
+
+	upper := middle + stdDevs*stdDev
+	lower := middle - stdDevs*stdDev
+
+	var percentB float64
+	if upper != lower {
+		percentB = (window[len(window)-1] - lower) / (upper - lower)
+	}
+	return upper, middle, lower, percentB
+}
+
+// ATR returns the Average True Range using Wilder's smoothing,
+// or 0 when there is not enough data
+func ATR(series Series, period int) float64 {
+	if period <= 0 || len(series) < period+1 {
+		return 0
+	}
+
+	var atr float64
+	for i := 1; i <= period; i++ {
+		atr += trueRange(series[i], series[i-1])
+	}
+	atr /= float64(period)
+
+	for i := period + 1; i < len(series); i++ {
+		atr = (atr*float64(period-1) + trueRange(series[i], series[i-1])) / float64(period)
+	}
+	return atr
+}
+
+// Stochastic returns the fast stochastic %K and its `dPeriod` average %D.
+// Both are 0 when there is not enough data.
+func Stochastic(series Series, kPeriod, dPeriod int) (float64, float64) {
+	if kPeriod <= 0 || dPeriod <= 0 || len(series) < kPeriod+dPeriod-1 {
+		return 0, 0
+	}
+
+	kValues := make([]float64, 0, dPeriod)
+	for end := len(series) - dPeriod + 1; end <= len(series); end++ {
+		window := series[end-kPeriod : end]
+		highest, lowest := window[0].High, window[0].Low
+		for _, bar := range window {
+			highest = math.Max(highest, bar.High)
+			lowest = math.Min(lowest, bar.Low)
+		}
+
+		k := 50.0 // Flat range, treat as mid-point
+		if highest != lowest {
+			k = 100 * (window[len(window)-1].Close - lowest) / (highest - lowest)
+		}
+		kValues = append(kValues, k)
+	}
+
+	return kValues[len(kValues)-1], SMA(kValues, dPeriod)
+}
+
+// trueRange returns the greatest of the bar's range and its gaps from the previous close
+func trueRange(bar, prev Bar) float64 {
+	return math.Max(bar.High-bar.Low, math.Max(math.Abs(bar.High-prev.Close), math.Abs(bar.Low-prev.Close)))
+}
@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go-stock/fileutil"
+	"go-stock/replay"
+)
+
+// refreshCache makes this process ignore cached replies, see RefreshCache
+var refreshCache bool
+
+// RefreshCache makes the rest of this run ask the model again instead of
+// reusing cached replies. The fresh replies still replace the cached ones.
+func RefreshCache() {
+	refreshCache = true
+}
+
+// cachedProvider answers requests that carry a CacheKey from replies saved
+// on disk, so reruns on the same data do not spend quota again
+type cachedProvider struct {
+	Provider
+	dir string
+	ttl time.Duration
+}
+
+// cacheEntry is one cached reply, stored as a JSON file
+type cacheEntry struct {
+	Provider string    `json:"provider"`
+	Model    string    `json:"model"`
+	Key      string    `json:"key"`
+	Created  time.Time `json:"created"`
+	Reply    string    `json:"reply"`
+}
+
+// WithCache returns a provider that reuses replies stored in dir for up to
+// ttl. Replies are keyed by provider, model and the request's CacheKey;
+// requests without a CacheKey always reach the model. Only replies the
+// request's Accept check passes are cached or reused.
+func WithCache(p Provider, dir string, ttl time.Duration) Provider {
+	return &cachedProvider{Provider: p, dir: dir, ttl: ttl}
+}
+
+// Generate implements Provider
+func (c *cachedProvider) Generate(req Request) (string, error) {
+	if req.CacheKey == "" {
+		return c.Provider.Generate(req)
+	}
+
+	path := c.path(req.CacheKey)
+	if !refreshCache {
+		if reply, ok := c.load(path, req.CacheKey); ok && accepted(req, reply) {
+			return reply, nil
+		}
+	}
+
+	reply, err := c.Provider.Generate(req)
+	if err != nil {
+		return "", err
+	}
+	if !accepted(req, reply) {
+		return reply, nil
+	}
+	entry := cacheEntry{Provider: c.Name(), Model: c.Model(), Key: req.CacheKey, Created: replay.Now(), Reply: reply}
+	if err := c.save(path, entry); err != nil {
+		fmt.Printf("Warning: could not cache model reply: %v\n", err)
+	}
+	return reply, nil
+}
+
+// accepted reports whether the request's Accept check, if any, passes the reply
+func accepted(req Request, reply string) bool {
+	return req.Accept == nil || req.Accept(reply) == nil
+}
+
+// path is the cache file for a key, named by a hash of provider, model and key
+func (c *cachedProvider) path(key string) string {
+	sum := sha256.Sum256([]byte(c.Name() + "\x00" + c.Model() + "\x00" + key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load returns the cached reply for key if there is one younger than the TTL
+func (c *cachedProvider) load(path, key string) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return "", false
+	}
+	if entry.Provider != c.Name() || entry.Model != c.Model() || entry.Key != key {
+		return "", false
+	}
+	if replay.Now().Sub(entry.Created) > c.ttl {
+		os.Remove(path)
+		return "", false
+	}
+	return entry.Reply, true
+}
+
+// save writes an entry to its cache file
+func (c *cachedProvider) save(path string, entry cacheEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFile(path, data, 0o644)
+}
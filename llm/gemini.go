@@ -0,0 +1,104 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go-stock/replay"
+)
+
+// Gemini calls Google's generateContent API
+type Gemini struct {
+	baseURL string
+	model   string
+	apiKey  string
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Name implements Provider
+func (g *Gemini) Name() string { return "gemini" }
+
+// Model implements Provider
+func (g *Gemini) Model() string { return g.model }
+
+// Generate implements Provider
+func (g *Gemini) Generate(req Request) (string, error) {
+	payload := map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{
+				"parts": []map[string]string{
+					{"text": req.Prompt},
+				},
+			},
+		},
+	}
+	if req.Schema != nil {
+		payload["generationConfig"] = map[string]interface{}{
+			"responseMimeType": "application/json",
+			"responseSchema":   geminiSchema(req.Schema),
+		}
+	}
+
+	resp, err := newClient().R().
+		SetHeader("Content-Type", "application/json").
+		SetQueryParam("key", g.apiKey).
+		SetBody(payload).
+		Post(fmt.Sprintf("%s/models/%s:generateContent", strings.TrimRight(g.baseURL, "/"), g.model))
+	if err != nil {
+		return "", fmt.Errorf("Gemini API request failed: %v", replay.Redact(err.Error()))
+	}
+
+	var geminiResp geminiResponse
+	if err := json.Unmarshal(resp.Body(), &geminiResp); err != nil {
+		return "", fmt.Errorf("failed to parse Gemini response: %v", err)
+	}
+	if geminiResp.Error != nil {
+		return "", fmt.Errorf("Gemini API error (status %d): %s", resp.StatusCode(), geminiResp.Error.Message)
+	}
+	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("no insights returned from Gemini API")
+	}
+
+	return geminiResp.Candidates[0].Content.Parts[0].Text, nil
+}
+
+// geminiSchema converts a JSON schema to Gemini's OpenAPI subset, which
+// spells types in upper case
+func geminiSchema(schema map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		switch v := value.(type) {
+		case string:
+			if key == "type" {
+				v = strings.ToUpper(v)
+			}
+			converted[key] = v
+		case map[string]interface{}:
+			if key == "properties" {
+				properties := make(map[string]interface{}, len(v))
+				for name, property := range v {
+					properties[name] = geminiSchema(property.(map[string]interface{}))
+				}
+				converted[key] = properties
+			} else {
+				converted[key] = geminiSchema(v)
+			}
+		default:
+			converted[key] = value
+		}
+	}
+	return converted
+}
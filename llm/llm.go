@@ -0,0 +1,81 @@
+// Package llm talks to the language models that write the AI insights.
+package llm
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"go-stock/config"
+	"go-stock/replay"
+)
+
+// Provider generates text with one model. Implementations must be safe to
+// reuse across requests.
+type Provider interface {
+	// Name identifies the backend, e.g. "gemini"
+	Name() string
+
+	// Model is the model the backend was configured with
+	Model() string
+
+	// Generate returns the model's reply to a request
+	Generate(req Request) (string, error)
+}
+
+// Request is a single prompt for the model
+type Request struct {
+	Prompt string
+
+	// Schema, if set, is a JSON schema the reply must follow. Backends ask
+	// for JSON output in whichever way their API supports.
+	Schema map[string]interface{}
+
+	// CacheKey, if set, identifies the prompt version and inputs behind the
+	// prompt, so a cached reply to the same key can be reused
+	CacheKey string
+
+	// Accept, if set, checks a reply before it is cached or reused from the
+	// cache, so replies the caller rejects are never kept
+	Accept func(reply string) error
+}
+
+// New returns the provider described by settings
+func New(settings config.LLMSettings) (Provider, error) {
+	switch settings.Provider {
+	case "gemini":
+		if settings.APIKey == "" {
+			return nil, fmt.Errorf("GEMINI_API_KEY not set")
+		}
+		return &Gemini{baseURL: settings.BaseURL, model: settings.Model, apiKey: settings.APIKey}, nil
+	case "openai":
+		return &OpenAI{baseURL: settings.BaseURL, model: settings.Model, apiKey: settings.APIKey}, nil
+	case "ollama":
+		return &Ollama{baseURL: settings.BaseURL, model: settings.Model}, nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", settings.Provider)
+	}
+}
+
+// Default returns the configured provider, reusing cached replies. The
+// cache is skipped in record and replay mode so fixtures see every request.
+func Default() (Provider, error) {
+	settings := config.GetConfig().LLM
+	p, err := New(settings)
+	if err != nil {
+		return nil, err
+	}
+	if replay.Mode() != replay.ModeLive {
+		return p, nil
+	}
+	return WithCache(p, settings.CacheDir, settings.CacheTTL), nil
+}
+
+// requestTimeout bounds one model call; local models can be slow
+const requestTimeout = 2 * time.Minute
+
+// newClient returns an HTTP client that honours HTTP_MODE record/replay
+func newClient() *resty.Client {
+	return resty.New().SetTransport(replay.Transport()).SetTimeout(requestTimeout)
+}
@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Ollama calls a local Ollama server's chat API
+type Ollama struct {
+	baseURL string
+	model   string
+}
+
+type ollamaResponse struct {
+	Message chatMessage `json:"message"`
+	Error   string      `json:"error"`
+}
+
+// Name implements Provider
+func (o *Ollama) Name() string { return "ollama" }
+
+// Model implements Provider
+func (o *Ollama) Model() string { return o.model }
+
+// Generate implements Provider
+func (o *Ollama) Generate(req Request) (string, error) {
+	payload := map[string]interface{}{
+		"model":    o.model,
+		"messages": []chatMessage{{Role: "user", Content: req.Prompt}},
+		"stream":   false,
+	}
+	if req.Schema != nil {
+		payload["format"] = req.Schema
+	}
+
+	resp, err := newClient().R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(payload).
+		Post(strings.TrimRight(o.baseURL, "/") + "/api/chat")
+	if err != nil {
+		return "", fmt.Errorf("Ollama request failed (is `ollama serve` running?): %v", err)
+	}
+
+	var chatResp ollamaResponse
+	if err := json.Unmarshal(resp.Body(), &chatResp); err != nil {
+		return "", fmt.Errorf("failed to parse Ollama response (status %d): %v", resp.StatusCode(), err)
+	}
+	if chatResp.Error != "" {
+		return "", fmt.Errorf("Ollama error: %s", chatResp.Error)
+	}
+	if chatResp.Message.Content == "" {
+		return "", fmt.Errorf("no insights returned from %s", o.model)
+	}
+
+	return chatResp.Message.Content, nil
+}
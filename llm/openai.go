@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// OpenAI calls any OpenAI-compatible chat completions endpoint, e.g. OpenAI,
+// Groq, OpenRouter, vLLM or LM Studio
+type OpenAI struct {
+	baseURL string
+	model   string
+	apiKey  string // Optional for local servers
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Name implements Provider
+func (o *OpenAI) Name() string { return "openai" }
+
+// Model implements Provider
+func (o *OpenAI) Model() string { return o.model }
+
+// Generate implements Provider
+func (o *OpenAI) Generate(req Request) (string, error) {
+	payload := map[string]interface{}{
+		"model":    o.model,
+		"messages": []chatMessage{{Role: "user", Content: req.Prompt}},
+	}
+	if req.Schema != nil {
+		payload["response_format"] = map[string]interface{}{
+			"type":        "json_schema",
+			"json_schema": map[string]interface{}{"name": "response", "schema": req.Schema},
+		}
+	}
+
+	request := newClient().R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(payload)
+	if o.apiKey != "" {
+		request.SetAuthToken(o.apiKey)
+	}
+
+	resp, err := request.Post(strings.TrimRight(o.baseURL, "/") + "/chat/completions")
+	if err != nil {
+		return "", fmt.Errorf("chat completion request failed: %v", err)
+	}
+
+	var chatResp openAIResponse
+	if err := json.Unmarshal(resp.Body(), &chatResp); err != nil {
+		return "", fmt.Errorf("failed to parse chat completion response (status %d): %v", resp.StatusCode(), err)
+	}
+	if chatResp.Error != nil {
+		return "", fmt.Errorf("chat completion error (status %d): %s", resp.StatusCode(), chatResp.Error.Message)
+	}
+	if len(chatResp.Choices) == 0 || chatResp.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("no insights returned from %s", o.model)
+	}
+
+	return chatResp.Choices[0].Message.Content, nil
+}
@@ -1,28 +1,139 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"go-stock/bot"
+	"go-stock/config"
+	"go-stock/llm"
 	"go-stock/marketfall"
+	"go-stock/notify"
+	"go-stock/replay"
+	"go-stock/scheduler"
+	"go-stock/scorecard"
 	"go-stock/stock"
+	"go-stock/storage"
 	"os"
+	"sort"
 )
 
+// tasks maps each task name to the function that runs it, recording what it
+// produced, and reports what it delivered. Every task can be run directly
+// or scheduled by the daemon.
+var tasks = map[string]func(run *storage.Run) notify.Summary{
+	"stock": func(run *storage.Run) notify.Summary {
+		fmt.Println("Running stock market analysis...")
+		return stock.RunStockAnalysis(run)
+	},
+	"marketfall": func(run *storage.Run) notify.Summary {
+		fmt.Println("Running market fall check...")
+		return marketfall.RunMarketFallCheck(run)
+	},
+	"scorecard": func(run *storage.Run) notify.Summary {
+		fmt.Println("Running recommendation scorecard...")
+		return scorecard.RunScorecard(run)
+	},
+}
+
+// runTask runs a task, prints its delivery summary and saves the run
+func runTask(name string, task func(run *storage.Run) notify.Summary) {
+	run := storage.NewRun(name)
+	summary := task(run)
+	summary.Print()
+
+	run.Finish(summary)
+	if err := storage.Save(run); err != nil {
+		fmt.Printf("Warning: could not save run history: %v\n", err)
+	}
+}
+
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Please specify which task to run: 'stock' or 'marketfall'")
+		fmt.Println("Please specify which task to run: 'stock', 'marketfall', 'scorecard', 'daemon', 'bot' or 'prompt'")
 		os.Exit(1)
 	}
 
+	if mode := replay.Mode(); mode != replay.ModeLive {
+		fmt.Printf("HTTP %s mode, fixtures in %s\n", mode, config.GetConfig().FixtureDir)
+	}
+
 	task := os.Args[1]
 	switch task {
-	case "stock":
-		fmt.Println("Running stock market analysis...")
-		stock.RunStockAnalysis()
-	case "marketfall":
-		fmt.Println("Running market fall check...")
-		marketfall.RunMarketFallCheck()
-	default:
-		fmt.Println("Invalid task. Please use 'stock' or 'marketfall'")
+	case "daemon":
+		parseFlags(task, os.Args[2:])
+		runDaemon()
+		return
+	case "bot":
+		if err := bot.Run(); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	case "prompt":
+		runPrompt(os.Args[2:])
+		return
+	}
+
+	run, ok := tasks[task]
+	if !ok {
+		fmt.Println("Invalid task. Please use 'stock', 'marketfall', 'scorecard', 'daemon', 'bot' or 'prompt'")
+		os.Exit(1)
+	}
+	parseFlags(task, os.Args[2:])
+	runTask(task, run)
+}
+
+// parseFlags handles the options shared by the tasks and the daemon
+func parseFlags(task string, args []string) {
+	flags := flag.NewFlagSet(task, flag.ExitOnError)
+	noCache := flags.Bool("no-cache", false, "ask the model again instead of reusing cached replies")
+	flags.Parse(args)
+
+	if *noCache {
+		llm.RefreshCache()
+	}
+}
+
+// runPrompt handles "prompt render SYMBOL", printing the prompt the model
+// would be sent for a symbol without calling it
+func runPrompt(args []string) {
+	if len(args) != 2 || args[0] != "render" {
+		fmt.Println("Usage: prompt render SYMBOL")
+		os.Exit(1)
+	}
+
+	p, err := stock.RenderPrompt(args[1])
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Prompt version: %s\n\n%s\n", p.Version, p.Text)
+}
+
+// runDaemon schedules every task that has a cron expression and blocks until shutdown
+func runDaemon() {
+	cfg := config.GetConfig()
+
+	var jobs []scheduler.Job
+	for name, spec := range cfg.Schedules {
+		name := name // Captured by the job below
+		run, ok := tasks[name]
+		if !ok {
+			fmt.Printf("Warning: no task named %s, ignoring its schedule\n", name)
+			continue
+		}
+		jobs = append(jobs, scheduler.Job{Name: name, Schedule: spec, Run: func() { runTask(name, run) }})
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+
+	if len(jobs) == 0 {
+		fmt.Println("No jobs scheduled, exiting")
+		os.Exit(1)
+	}
+
+	fmt.Println("Starting daemon...")
+	if err := scheduler.Run(jobs, cfg.Timezone); err != nil {
+		fmt.Println("Error:", err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,234 @@
+// Package marketcap classifies symbols as large, mid or small cap using the
+// SEBI/AMFI rank-based definitions: the top 100 companies by full market
+// capitalisation are large cap, ranks 101-250 are mid cap and the rest are
+// small cap. Classifications are cached on disk and refreshed periodically.
+package marketcap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-stock/config"
+	"go-stock/fileutil"
+	"go-stock/replay"
+)
+
+// Class is a SEBI market-cap category
+type Class string
+
+const (
+	LargeCap Class = "large-cap"
+	MidCap   Class = "mid-cap"
+	SmallCap Class = "small-cap"
+	Unknown  Class = "unknown"
+)
+
+// Classes lists the categories in report order
+var Classes = []Class{LargeCap, MidCap, SmallCap, Unknown}
+
+// Label returns a human-readable name such as "Large Cap"
+func (c Class) Label() string {
+	switch c {
+	case LargeCap:
+		return "Large Cap"
+	case MidCap:
+		return "Mid Cap"
+	case SmallCap:
+		return "Small Cap"
+	default:
+		return "Unclassified"
+	}
+}
+
+// SEBI rank cut-offs
+const (
+	largeCapMaxRank = 100
+	midCapMaxRank   = 250
+)
+
+// ClassForRank applies the SEBI rank-based definition
+func ClassForRank(rank int) Class {
+	switch {
+	case rank <= 0:
+		return Unknown
+	case rank <= largeCapMaxRank:
+		return LargeCap
+	case rank <= midCapMaxRank:
+		return MidCap
+	default:
+		return SmallCap
+	}
+}
+
+// Classification is the cached result for one symbol
+type Classification struct {
+	Symbol         string    `json:"symbol"`
+	Class          Class     `json:"class"`
+	Rank           int       `json:"rank,omitempty"`             // AMFI rank, when known
+	MarketCapCrore float64   `json:"market_cap_crore,omitempty"` // Market cap in ₹ crore, when known
+	Source         string    `json:"source"`                     // "amfi" or "yahoo"
+	UpdatedAt      time.Time `json:"updated_at"`                 // When it was last classified
+	FailedAt       time.Time `json:"failed_at,omitempty"`        // When a refresh last failed, if it did
+}
+
+// failedRetryAfter is how long a failed lookup is cached before it is tried again
+const failedRetryAfter = 6 * time.Hour
+
+// Classifier looks up classifications, refreshing stale cache entries
+type Classifier struct {
+	settings config.MarketCapSettings
+	yahoo    *yahooFundamentals
+
+	mu    sync.Mutex
+	cache map[string]Classification
+	amfi  map[string]Classification // Loaded lazily, nil until first refresh
+}
+
+// NewClassifier creates a classifier from the given settings
+func NewClassifier(settings config.MarketCapSettings) *Classifier {
+	c := &Classifier{
+		settings: settings,
+		yahoo:    newYahooFundamentals(),
+		cache:    make(map[string]Classification),
+	}
+	if err := c.loadCache(); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: ignoring market-cap cache %s: %v\n", settings.CacheFile, err)
+	}
+	return c
+}
+
+// Default returns a classifier using the configured settings
+func Default() *Classifier {
+	return NewClassifier(config.GetConfig().MarketCap)
+}
+
+// Classify returns the class of every symbol, fetching only those whose
+// cached classification is missing or older than the refresh interval.
+// A failed lookup keeps the previous class (or Unknown) and is not retried
+// for failedRetryAfter.
+func (c *Classifier) Classify(symbols []string) map[string]Classification {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := replay.Now()
+	maxAge := time.Duration(c.settings.RefreshDays) * 24 * time.Hour
+	result := make(map[string]Classification)
+	updated := false
+
+	for _, symbol := range symbols {
+		cached, ok := c.cache[symbol]
+		if ok && (now.Sub(cached.UpdatedAt) < maxAge || now.Sub(cached.FailedAt) < failedRetryAfter) {
+			result[symbol] = cached
+			continue
+		}
+
+		classification, err := c.fetch(symbol)
+		if err != nil {
+			fmt.Printf("Warning: could not classify %s: %v\n", symbol, err)
+			if !ok {
+				cached = Classification{Symbol: symbol, Class: Unknown}
+			}
+			cached.FailedAt = now // A stale class is better than none
+			c.cache[symbol] = cached
+			result[symbol] = cached
+			updated = true
+			continue
+		}
+
+		classification.UpdatedAt = now
+		c.cache[symbol] = classification
+		result[symbol] = classification
+		updated = true
+	}
+
+	if updated {
+		if err := c.saveCache(); err != nil {
+			fmt.Printf("Warning: failed to save market-cap cache: %v\n", err)
+		}
+	}
+	return result
+}
+
+// fetch classifies a symbol from the AMFI list, falling back to Yahoo market cap
+func (c *Classifier) fetch(symbol string) (Classification, error) {
+	if c.settings.AMFIFile != "" {
+		if c.amfi == nil {
+			amfi, err := loadAMFI(c.settings.AMFIFile)
+			if err != nil {
+				fmt.Printf("Warning: failed to load AMFI classification: %v\n", err)
+				amfi = map[string]Classification{}
+			}
+			c.amfi = amfi
+		}
+		if classification, ok := c.amfi[amfiKey(symbol)]; ok {
+			classification.Symbol = symbol
+			return classification, nil
+		}
+	}
+
+	marketCap, err := c.yahoo.marketCapCrore(symbol)
+	if err != nil {
+		return Classification{}, err
+	}
+	return Classification{
+		Symbol:         symbol,
+		Class:          c.classForMarketCap(marketCap),
+		MarketCapCrore: marketCap,
+		Source:         "yahoo",
+	}, nil
+}
+
+// classForMarketCap approximates the rank-based definition with the market
+// caps of the 100th and 250th companies in the latest AMFI list
+func (c *Classifier) classForMarketCap(crore float64) Class {
+	switch {
+	case crore >= c.settings.LargeCapMinCrore:
+		return LargeCap
+	case crore >= c.settings.MidCapMinCrore:
+		return MidCap
+	case crore > 0:
+		return SmallCap
+	default:
+		return Unknown
+	}
+}
+
+func (c *Classifier) loadCache() error {
+	data, err := os.ReadFile(c.settings.CacheFile)
+	if err != nil {
+		return err
+	}
+	var entries []Classification
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		c.cache[entry.Symbol] = entry
+	}
+	return nil
+}
+
+func (c *Classifier) saveCache() error {
+	entries := make([]Classification, 0, len(c.cache))
+	for _, entry := range c.cache {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Symbol < entries[j].Symbol })
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFile(c.settings.CacheFile, data, 0o644)
+}
+
+// amfiKey normalises a Yahoo ticker (RELIANCE.NS) to an AMFI NSE symbol (RELIANCE)
+func amfiKey(symbol string) string {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	return strings.TrimSuffix(strings.TrimSuffix(symbol, ".NS"), ".BO")
+}
@@ -0,0 +1,216 @@
+package marketcap
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"go-stock/replay"
+)
+
+// loadAMFI reads AMFI's half-yearly market-cap classification, exported as
+// CSV, from a local path or an http(s) URL. Columns are matched by header:
+// a rank ("Sr. No." or "Rank"), an "NSE Symbol" and optionally the SEBI
+// categorisation and average market cap.
+func loadAMFI(source string) (map[string]Classification, error) {
+	data, err := readSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid AMFI CSV: %v", err)
+	}
+
+	// The export may have title rows, so find the first row that looks like a header
+	header := -1
+	rankCol, symbolCol, categoryCol, capCol := -1, -1, -1, -1
+	for i, row := range rows {
+		for j, cell := range row {
+			name := strings.ToLower(strings.TrimSpace(cell))
+			switch {
+			case name == "rank" || strings.HasPrefix(name, "sr"):
+				rankCol = j
+			case strings.Contains(name, "nse symbol"):
+				symbolCol = j
+			case strings.Contains(name, "categori"):
+				categoryCol = j
+			case strings.Contains(name, "average of all exchanges"):
+				capCol = j
+			}
+		}
+		if rankCol >= 0 && symbolCol >= 0 {
+			header = i
+			break
+		}
+		rankCol, symbolCol, categoryCol, capCol = -1, -1, -1, -1
+	}
+	if header < 0 {
+		return nil, fmt.Errorf("AMFI CSV has no rank and NSE Symbol columns")
+	}
+
+	result := make(map[string]Classification)
+	for _, row := range rows[header+1:] {
+		if symbolCol >= len(row) || rankCol >= len(row) {
+			continue
+		}
+		symbol := strings.ToUpper(strings.TrimSpace(row[symbolCol]))
+		rank, err := strconv.Atoi(strings.TrimSpace(row[rankCol]))
+		if symbol == "" || err != nil {
+			continue
+		}
+
+		classification := Classification{Rank: rank, Class: ClassForRank(rank), Source: "amfi"}
+		if categoryCol >= 0 && categoryCol < len(row) {
+			if class := parseCategory(row[categoryCol]); class != Unknown {
+				classification.Class = class
+			}
+		}
+		if capCol >= 0 && capCol < len(row) {
+			classification.MarketCapCrore, _ = strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(row[capCol]), ",", ""), 64)
+		}
+		result[symbol] = classification
+	}
+	return result, nil
+}
+
+// parseCategory maps AMFI's "Large Cap"/"Mid Cap"/"Small Cap" labels
+func parseCategory(value string) Class {
+	value = strings.ToLower(value)
+	switch {
+	case strings.Contains(value, "large"):
+		return LargeCap
+	case strings.Contains(value, "mid"):
+		return MidCap
+	case strings.Contains(value, "small"):
+		return SmallCap
+	default:
+		return Unknown
+	}
+}
+
+// readSource reads a local file or downloads an http(s) URL
+func readSource(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+
+	resp, err := replay.Client(30 * time.Second).Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("fetching %s: status %d", source, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// yahooFundamentals fetches market capitalisation from Yahoo quoteSummary,
+// which only answers requests carrying a session cookie and its crumb
+type yahooFundamentals struct {
+	client *resty.Client
+	crumb  string // Fetched on first use and again when Yahoo rejects it
+}
+
+func newYahooFundamentals() *yahooFundamentals {
+	client := resty.New().
+		SetRetryCount(3).
+		SetRetryWaitTime(2*time.Second).
+		SetRetryMaxWaitTime(10*time.Second).
+		SetHeader("User-Agent", "Mozilla/5.0").
+		SetTransport(replay.Transport())
+	return &yahooFundamentals{client: client}
+}
+
+// Yahoo endpoints for the cookie/crumb handshake
+const (
+	yahooCookieURL = "https://fc.yahoo.com"
+	yahooCrumbURL  = "https://query2.finance.yahoo.com/v1/test/getcrumb"
+)
+
+// session fetches the cookie and crumb quoteSummary requires. The client's
+// cookie jar keeps the cookie for later requests.
+func (y *yahooFundamentals) session() error {
+	// fc.yahoo.com answers 404 but sets the session cookie
+	if _, err := y.client.R().Get(yahooCookieURL); err != nil {
+		return fmt.Errorf("failed to start Yahoo session: %v", err)
+	}
+	resp, err := y.client.R().Get(yahooCrumbURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch Yahoo crumb: %v", err)
+	}
+	crumb := strings.TrimSpace(resp.String())
+	if resp.StatusCode() != 200 || crumb == "" || strings.ContainsAny(crumb, "{<") {
+		return fmt.Errorf("failed to fetch Yahoo crumb: status %d", resp.StatusCode())
+	}
+	y.crumb = crumb
+	return nil
+}
+
+// marketCapCrore returns the symbol's market cap in ₹ crore
+func (y *yahooFundamentals) marketCapCrore(symbol string) (float64, error) {
+	if y.crumb == "" {
+		if err := y.session(); err != nil {
+			return 0, err
+		}
+	}
+	resp, err := y.quoteSummary(symbol)
+	if err == nil && (resp.StatusCode() == 401 || resp.StatusCode() == 403) {
+		// The crumb expired; start a new session once
+		if err := y.session(); err != nil {
+			return 0, err
+		}
+		resp, err = y.quoteSummary(symbol)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch fundamentals for %s: %v", symbol, err)
+	}
+	if resp.StatusCode() != 200 {
+		return 0, fmt.Errorf("failed to fetch fundamentals for %s: status %d", symbol, resp.StatusCode())
+	}
+
+	var result struct {
+		QuoteSummary struct {
+			Result []struct {
+				Price struct {
+					Currency  string `json:"currency"`
+					MarketCap struct {
+						Raw float64 `json:"raw"`
+					} `json:"marketCap"`
+				} `json:"price"`
+			} `json:"result"`
+		} `json:"quoteSummary"`
+	}
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		return 0, fmt.Errorf("failed to parse fundamentals for %s: %v", symbol, err)
+	}
+	if len(result.QuoteSummary.Result) == 0 || result.QuoteSummary.Result[0].Price.MarketCap.Raw <= 0 {
+		return 0, fmt.Errorf("no market cap returned for %s", symbol)
+	}
+
+	price := result.QuoteSummary.Result[0].Price
+	if price.Currency != "" && price.Currency != "INR" {
+		return 0, fmt.Errorf("market cap for %s is in %s, not INR", symbol, price.Currency)
+	}
+	return price.MarketCap.Raw / 1e7, nil // 1 crore = 10 million
+}
+
+func (y *yahooFundamentals) quoteSummary(symbol string) (*resty.Response, error) {
+	return y.client.R().
+		SetQueryParam("modules", "price").
+		SetQueryParam("crumb", y.crumb).
+		Get(fmt.Sprintf("https://query2.finance.yahoo.com/v10/finance/quoteSummary/%s", symbol))
+}
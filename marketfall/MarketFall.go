@@ -4,7 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"go-stock/calendar"
 	"go-stock/config"
+	"go-stock/notify"
+	"go-stock/render"
+	"go-stock/replay"
+	"go-stock/storage"
 	"io/ioutil"
 	"net/http"
 	"strconv"
@@ -22,43 +27,17 @@ type IndexRequest struct {
 
 // Function to get current date and date one week ago in required format
 func getDates() (string, string) {
-	endDate := time.Now().Format("02-Jan-2006")
-	startDate := time.Now().AddDate(0, 0, -7).Format("02-Jan-2006")
+	now := replay.Now()
+	endDate := now.Format("02-Jan-2006")
+	startDate := now.AddDate(0, 0, -7).Format("02-Jan-2006")
 	return startDate, endDate
 }
 
-// Function to send Telegram notification
-func sendTelegramNotification(message string) {
+// Function to send the report through the configured notifiers
+func sendNotification(message notify.Message) []notify.Delivery {
 	cfg := config.GetConfig()
-	if cfg.TelegramBotToken == "" || len(cfg.TelegramChatIDs) == 0 {
-		fmt.Println("Warning: Telegram credentials not set")
-		return
-	}
-
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", cfg.TelegramBotToken)
-
-	// Send to each chat ID
-	for _, chatID := range cfg.TelegramChatIDs {
-		payload := map[string]string{
-			"chat_id": chatID,
-			"text":    message,
-		}
-
-		payloadBytes, _ := json.Marshal(payload)
-
-		resp, err := http.Post(url, "application/json", bytes.NewBuffer(payloadBytes))
-		if err != nil {
-			fmt.Printf("Error sending Telegram notification to chat %s: %v\n", chatID, err)
-			continue
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode == http.StatusOK {
-			fmt.Printf("Notification sent successfully to chat %s!\n", chatID)
-		} else {
-			fmt.Printf("Failed to send notification to chat %s. Status code: %d\n", chatID, resp.StatusCode)
-		}
-	}
+	notifiers := notify.ForNames(cfg.MarketFallNotify, cfg.TelegramChatIDs)
+	return notify.SendAll(notifiers, message)
 }
 
 // Function to fetch data from the API
@@ -85,7 +64,7 @@ func fetchIndexData(index IndexRequest) (string, error) {
 		req.Header.Set(key, value)
 	}
 
-	client := &http.Client{}
+	client := replay.Client(30 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
@@ -104,11 +83,36 @@ func fetchIndexData(index IndexRequest) (string, error) {
 	return strings.TrimSpace(output), nil
 }
 
-// RunMarketFallCheck executes the market fall check
-func RunMarketFallCheck() {
+// RunMarketFallCheck executes the market fall check, recording the index
+// returns in run, and returns what was delivered
+func RunMarketFallCheck(run *storage.Run) notify.Summary {
+	var summary notify.Summary
+	cfg := config.GetConfig()
+	status := calendar.Default().StatusAt(replay.Now())
+	if !status.TradingDay && cfg.MarketClosedPolicy == "skip" {
+		fmt.Printf("Market closed today (%s), skipping market fall check\n", status.Reason)
+		return summary
+	}
+
+	message := Report(status, run)
+	fmt.Println(message.Text())
+	summary.Add(sendNotification(message)...)
+	return summary
+}
+
+// Report fetches last week's index returns, records them in run (which may
+// be nil) and builds the market fall message, labelled when the market is
+// shut today
+func Report(status calendar.Status, run *storage.Run) notify.Message {
 	// Define the start and end dates
 	startDate, endDate := getDates()
 
+	// Label the report when the latest data is from an earlier session
+	var label string
+	if l := status.Label(); l != "" {
+		label = " (" + l + ")"
+	}
+
 	// Define the index data
 	indices := []IndexRequest{
 		{Name: "Nifty 50", StartDate: startDate, EndDate: endDate},
@@ -138,26 +142,40 @@ func RunMarketFallCheck() {
 			continue
 		}
 
+		run.AddIndexReturn(storage.IndexReturn{
+			Index:     index.Name,
+			Date:      storage.Date(replay.Now()),
+			StartDate: index.StartDate,
+			EndDate:   index.EndDate,
+			Return:    returnValue,
+		})
 		returns = append(returns, returnValue)
 		messages = append(messages, fmt.Sprintf("%s: %f", index.Name, returnValue))
 	}
 
-	// Check if all returns are negative and send a Telegram notification if so
-	allNegative := true
+	if len(returns) == 0 {
+		fmt.Println("No index returns could be fetched.")
+		return notify.Message{Title: render.Text("Market fall check failed: no index data" + label)}
+	}
+	if !allNegative(returns) {
+		fmt.Println("Not all returns are negative.")
+		return notify.Message{Title: render.Text("Mutual Funds : Not a big gap" + label)}
+	}
+	return notify.Message{
+		Title:    render.Text(fmt.Sprintf("Indices are negative, from: %s to: %s%s", startDate, endDate, label)),
+		Sections: []render.Document{render.Text(strings.Join(messages, "\n"))},
+	}
+}
+
+// allNegative reports whether there is at least one return and every return is below zero
+func allNegative(returns []float64) bool {
+	if len(returns) == 0 {
+		return false
+	}
 	for _, ret := range returns {
 		if ret >= 0 {
-			allNegative = false
-			break
+			return false
 		}
 	}
-
-	if allNegative {
-		message := fmt.Sprintf("Indices are negative, from: %s to: %s\n%s", startDate, endDate, strings.Join(messages, "\n"))
-		fmt.Println(message)
-		sendTelegramNotification(message)
-	} else {
-		fmt.Println("Not all returns are negative.")
-		message := "Mutual Funds : Not a big gap"
-		sendTelegramNotification(message)
-	}
+	return true
 }
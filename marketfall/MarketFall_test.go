@@ -0,0 +1,22 @@
+package marketfall
+
+import "testing"
+
+func TestAllNegative(t *testing.T) {
+	tests := []struct {
+		name    string
+		returns []float64
+		want    bool
+	}{
+		{"all negative", []float64{-1.2, -0.3, -4}, true},
+		{"one flat", []float64{-1.2, 0, -4}, false},
+		{"one positive", []float64{-1.2, 0.5}, false},
+		{"no returns", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := allNegative(tt.returns); got != tt.want {
+			t.Errorf("%s: allNegative(%v) = %v, want %v", tt.name, tt.returns, got, tt.want)
+		}
+	}
+}
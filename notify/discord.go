@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"fmt"
+
+	"go-stock/render"
+)
+
+// discordLimit is the maximum content length of a Discord message
+const discordLimit = 2000
+
+// Discord posts messages to a Discord webhook
+type Discord struct {
+	name       string
+	webhookURL string
+}
+
+// NewDiscord creates a Discord webhook notifier
+func NewDiscord(name, webhookURL string) (*Discord, error) {
+	if webhookURL == "" {
+		return nil, fmt.Errorf("discord webhook_url not set")
+	}
+	return &Discord{name: name, webhookURL: webhookURL}, nil
+}
+
+// Name implements Notifier
+func (d *Discord) Name() string { return d.name }
+
+// Send implements Notifier. Messages over Discord's limit are split between sections.
+func (d *Discord) Send(msg Message) Delivery {
+	posts := splitMessage(msg, render.Discord, discordLimit)
+	delivery := Delivery{Notifier: d.name}
+	for i, post := range posts {
+		err := postJSON(d.webhookURL, map[string]string{"content": render.Discord.Render(post)}, nil)
+		delivery.Results = append(delivery.Results, Result{Target: "discord webhook", Part: i + 1, Parts: len(posts), Err: err})
+	}
+	return delivery
+}
@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+
+	"go-stock/config"
+	"go-stock/render"
+)
+
+// Email sends messages through an SMTP server
+type Email struct {
+	name     string
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// NewEmail creates an SMTP email notifier
+func NewEmail(name string, settings config.NotifierConfig) (*Email, error) {
+	if settings.SMTPHost == "" || settings.From == "" || len(settings.To) == 0 {
+		return nil, fmt.Errorf("email notifier needs smtp_host, from and to")
+	}
+	port := settings.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	return &Email{
+		name:     name,
+		host:     settings.SMTPHost,
+		port:     port,
+		username: settings.Username,
+		password: settings.Password,
+		from:     settings.From,
+		to:       settings.To,
+	}, nil
+}
+
+// Name implements Notifier
+func (e *Email) Name() string { return e.name }
+
+// Send implements Notifier. The message is sent as plain text, one email to all recipients.
+func (e *Email) Send(msg Message) Delivery {
+	subject := strings.ReplaceAll(render.PlainText.Render(msg.Title), "\n", " ")
+	if subject == "" {
+		subject = "Stock report"
+	}
+
+	body := "From: " + e.from + "\r\n" +
+		"To: " + strings.Join(e.to, ", ") + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("UTF-8", subject) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		strings.ReplaceAll(msg.Text(), "\n", "\r\n")
+
+	var auth smtp.Auth
+	if e.username != "" {
+		auth = smtp.PlainAuth("", e.username, e.password, e.host)
+	}
+
+	// smtp.SendMail upgrades to STARTTLS when the server offers it
+	err := smtp.SendMail(fmt.Sprintf("%s:%d", e.host, e.port), auth, e.from, e.to, []byte(body))
+	return Delivery{Notifier: e.name, Results: []Result{{Target: strings.Join(e.to, ", "), Err: err}}}
+}
@@ -0,0 +1,219 @@
+// Package notify delivers reports to chat and email backends.
+package notify
+
+import (
+	"errors"
+	"fmt"
+
+	"go-stock/config"
+	"go-stock/render"
+)
+
+// Message is a report to deliver. Each backend renders the title and
+// sections with its own markup and escaping.
+type Message struct {
+	Title    render.Document   // Headline, e.g. "📊 Large Cap Stocks - 16-Oct-2026"
+	Sections []render.Document // Body sections, e.g. one per stock
+	Images   []Image           // Sent after the text by backends that support images
+}
+
+// Image is a PNG attachment, e.g. a stock chart
+type Image struct {
+	Name    string // File name, e.g. "RELIANCE.NS.png"
+	PNG     []byte
+	Caption string // Plain text
+}
+
+// Document returns the whole message as one document, with a blank line
+// after the title and a rule between sections
+func (m Message) Document() render.Document {
+	doc := append(render.Document{}, m.Title...)
+	for i, section := range m.Sections {
+		if i == 0 {
+			if len(doc) > 0 {
+				doc = append(doc, render.Line{})
+			}
+		} else {
+			doc = append(doc, render.Rule{})
+		}
+		doc = append(doc, section...)
+	}
+	return doc
+}
+
+// Text returns the whole message as plain text
+func (m Message) Text() string {
+	return render.PlainText.Render(m.Document())
+}
+
+// Notifier delivers messages to one backend
+type Notifier interface {
+	// Name identifies the notifier in delivery summaries
+	Name() string
+
+	// Send delivers the message to every target of the notifier
+	Send(msg Message) Delivery
+}
+
+// Result is the outcome of delivering to one target (a chat, address or URL).
+// Messages split into several parts have one result per part.
+type Result struct {
+	Target string
+	Part   int    // 1-based part number, 0 when the message was sent whole
+	Parts  int    // Total number of parts
+	Note   string // How delivery deviated from the norm, e.g. "sent as plain text"
+	Err    error
+}
+
+// describe returns the target along with the part number, if any
+func (r Result) describe() string {
+	if r.Parts > 1 {
+		return fmt.Sprintf("%s (part %d/%d)", r.Target, r.Part, r.Parts)
+	}
+	return r.Target
+}
+
+// Delivery summarises one Send call
+type Delivery struct {
+	Notifier string
+	Results  []Result
+}
+
+// Err returns the combined error of all failed targets, or nil
+func (d Delivery) Err() error {
+	var errs []error
+	for _, r := range d.Results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", d.Notifier, r.describe(), r.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Print writes a one-line status per target
+func (d Delivery) Print() {
+	for _, r := range d.Results {
+		switch {
+		case r.Err != nil:
+			fmt.Printf("Failed to send %s notification to %s: %v\n", d.Notifier, r.describe(), r.Err)
+		case r.Note != "":
+			fmt.Printf("Notification sent via %s to %s (%s)\n", d.Notifier, r.describe(), r.Note)
+		default:
+			fmt.Printf("Notification sent successfully via %s to %s!\n", d.Notifier, r.describe())
+		}
+	}
+}
+
+// Summary collects every delivery made during one run
+type Summary struct {
+	Deliveries []Delivery
+}
+
+// Add records deliveries in the summary
+func (s *Summary) Add(deliveries ...Delivery) {
+	s.Deliveries = append(s.Deliveries, deliveries...)
+}
+
+// Counts returns the number of messages (or parts) sent and failed
+func (s Summary) Counts() (sent, failed int) {
+	for _, d := range s.Deliveries {
+		for _, r := range d.Results {
+			if r.Err != nil {
+				failed++
+			} else {
+				sent++
+			}
+		}
+	}
+	return sent, failed
+}
+
+// Err returns the combined error of all failed deliveries, or nil
+func (s Summary) Err() error {
+	var errs []error
+	for _, d := range s.Deliveries {
+		if err := d.Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Print writes the status of every delivery followed by the totals
+func (s Summary) Print() {
+	for _, d := range s.Deliveries {
+		d.Print()
+	}
+	if sent, failed := s.Counts(); sent+failed > 0 {
+		fmt.Printf("Delivered %d of %d notifications\n", sent, sent+failed)
+	}
+}
+
+// TelegramNotifierName is the built-in notifier that uses TELEGRAM_BOT_TOKEN
+const TelegramNotifierName = "telegram"
+
+// New builds the named notifier. The built-in "telegram" notifier sends to
+// chatIDs; notifiers defined in the config file are built from their settings.
+func New(name string, chatIDs []string) (Notifier, error) {
+	cfg := config.GetConfig()
+
+	settings, ok := cfg.Notifiers[name]
+	if !ok {
+		if name != TelegramNotifierName {
+			return nil, fmt.Errorf("unknown notifier %q", name)
+		}
+		settings = config.NotifierConfig{Type: "telegram"}
+	}
+
+	switch settings.Type {
+	case "telegram":
+		token := settings.BotToken
+		if token == "" {
+			token = cfg.TelegramBotToken
+		}
+		if len(settings.ChatIDs) > 0 {
+			chatIDs = settings.ChatIDs
+		}
+		parseMode := settings.ParseMode
+		if parseMode == "" {
+			parseMode = cfg.TelegramParseMode
+		}
+		if token == "" || len(chatIDs) == 0 {
+			return nil, fmt.Errorf("telegram credentials not set")
+		}
+		return NewTelegram(name, token, parseMode, chatIDs), nil
+	case "slack":
+		return NewSlack(name, settings.WebhookURL)
+	case "discord":
+		return NewDiscord(name, settings.WebhookURL)
+	case "email":
+		return NewEmail(name, settings)
+	case "webhook":
+		return NewWebhook(name, settings.URL, settings.Headers)
+	default:
+		return nil, fmt.Errorf("notifier %q has unknown type %q", name, settings.Type)
+	}
+}
+
+// ForNames builds each named notifier, printing a warning for any that cannot be built
+func ForNames(names []string, chatIDs []string) []Notifier {
+	var notifiers []Notifier
+	for _, name := range names {
+		n, err := New(name, chatIDs)
+		if err != nil {
+			fmt.Printf("Warning: skipping notifier %s: %v\n", name, err)
+			continue
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers
+}
+
+// SendAll delivers msg through every notifier and returns their deliveries
+func SendAll(notifiers []Notifier, msg Message) []Delivery {
+	deliveries := make([]Delivery, 0, len(notifiers))
+	for _, n := range notifiers {
+		deliveries = append(deliveries, n.Send(msg))
+	}
+	return deliveries
+}
@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"fmt"
+
+	"go-stock/render"
+)
+
+// Slack posts messages to a Slack incoming webhook
+type Slack struct {
+	name       string
+	webhookURL string
+}
+
+// NewSlack creates a Slack incoming-webhook notifier
+func NewSlack(name, webhookURL string) (*Slack, error) {
+	if webhookURL == "" {
+		return nil, fmt.Errorf("slack webhook_url not set")
+	}
+	return &Slack{name: name, webhookURL: webhookURL}, nil
+}
+
+// Name implements Notifier
+func (s *Slack) Name() string { return s.name }
+
+// Send implements Notifier. The message is rendered as Slack mrkdwn.
+func (s *Slack) Send(msg Message) Delivery {
+	payload := map[string]interface{}{
+		"text":   render.Slack.Render(msg.Document()),
+		"mrkdwn": true,
+	}
+	err := postJSON(s.webhookURL, payload, nil)
+	return Delivery{Notifier: s.name, Results: []Result{{Target: "slack webhook", Err: err}}}
+}
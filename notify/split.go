@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"go-stock/render"
+)
+
+// splitMessage packs a message into documents that each render with r to at
+// most limit UTF-16 code units (the unit Telegram counts in). Parts break
+// between sections where possible; a section that is too long on its own is
+// broken between blocks, and a paragraph or code block between lines. Every
+// part is a complete document, so its markup is valid on its own. When there
+// is more than one part, each starts with the title and its number, e.g.
+// "Part 2/3".
+func splitMessage(msg Message, r render.Renderer, limit int) []render.Document {
+	whole := msg.Document()
+	if renderedLength(r, whole) <= limit {
+		return []render.Document{whole}
+	}
+
+	// Reserve room for the title and counter on every part
+	budget := limit - renderedLength(r, partHeader(msg.Title, 99, 99)) - 1
+	if budget <= 0 {
+		budget = limit / 2
+	}
+
+	var chunks []render.Document
+	for _, section := range msg.Sections {
+		chunks = append(chunks, splitSection(section, r, budget)...)
+	}
+
+	var bodies []render.Document
+	var current render.Document
+	for _, chunk := range chunks {
+		candidate := chunk
+		if current != nil {
+			candidate = append(append(append(render.Document{}, current...), render.Rule{}), chunk...)
+		}
+		if current != nil && renderedLength(r, candidate) > budget {
+			bodies = append(bodies, current)
+			candidate = chunk
+		}
+		current = candidate
+	}
+	if current != nil {
+		bodies = append(bodies, current)
+	}
+
+	parts := make([]render.Document, len(bodies))
+	for i, body := range bodies {
+		parts[i] = append(partHeader(msg.Title, i+1, len(bodies)), body...)
+	}
+	return parts
+}
+
+// partHeader is the title followed by the part counter and a blank line
+func partHeader(title render.Document, part, parts int) render.Document {
+	header := append(render.Document{}, title...)
+	return append(header, render.Line{render.Italic(fmt.Sprintf("Part %d/%d", part, parts))}, render.Line{})
+}
+
+// splitSection breaks a section into chunks that render within budget,
+// only between blocks or between the lines of a block
+func splitSection(section render.Document, r render.Renderer, budget int) []render.Document {
+	if renderedLength(r, section) <= budget {
+		return []render.Document{section}
+	}
+
+	var chunks []render.Document
+	var current render.Document
+	for _, b := range section {
+		for _, piece := range splitBlock(b, r, budget) {
+			candidate := append(append(render.Document{}, current...), piece)
+			if len(current) > 0 && renderedLength(r, candidate) > budget {
+				chunks = append(chunks, current)
+				candidate = render.Document{piece}
+			}
+			current = candidate
+		}
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// splitBlock breaks an over-long paragraph or code block into blocks of the
+// same kind that each render within budget. Other blocks are returned as is.
+func splitBlock(b render.Block, r render.Renderer, budget int) []render.Block {
+	var text string
+	var wrap func(string) render.Block
+	switch b := b.(type) {
+	case render.Paragraph:
+		text, wrap = string(b), func(s string) render.Block { return render.Paragraph(s) }
+	case render.Pre:
+		text, wrap = string(b), func(s string) render.Block { return render.Pre(s) }
+	default:
+		return []render.Block{b}
+	}
+	fits := func(s string) bool { return renderedLength(r, render.Document{wrap(s)}) <= budget }
+	if fits(text) {
+		return []render.Block{b}
+	}
+
+	var blocks []render.Block
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		for _, piece := range splitToFit(line, fits) {
+			if len(lines) > 0 && !fits(strings.Join(append(lines, piece), "\n")) {
+				blocks = append(blocks, wrap(strings.Join(lines, "\n")))
+				lines = nil
+			}
+			lines = append(lines, piece)
+		}
+	}
+	if len(lines) > 0 {
+		blocks = append(blocks, wrap(strings.Join(lines, "\n")))
+	}
+	return blocks
+}
+
+// splitToFit breaks a single line into pieces that each fit, shrinking the
+// piece size until escaping no longer pushes any piece over
+func splitToFit(line string, fits func(string) bool) []string {
+	if fits(line) {
+		return []string{line}
+	}
+	for size := textLength(line) / 2; size > 1; size = size * 3 / 4 {
+		pieces := splitLongLine(line, size)
+		ok := true
+		for _, piece := range pieces {
+			if !fits(piece) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return pieces
+		}
+	}
+	return []string{line}
+}
+
+// renderedLength returns the UTF-16 length of doc rendered with r
+func renderedLength(r render.Renderer, doc render.Document) int {
+	return textLength(r.Render(doc))
+}
+
+// splitLongLine breaks a single over-long line at spaces (or anywhere, as a
+// last resort) so no piece exceeds budget
+func splitLongLine(line string, budget int) []string {
+	if budget <= 0 || textLength(line) <= budget {
+		return []string{line}
+	}
+
+	var pieces []string
+	rest := line
+	for textLength(rest) > budget {
+		cut := prefixWithin(rest, budget)
+		if space := strings.LastIndex(rest[:cut], " "); space > 0 {
+			cut = space
+		}
+		pieces = append(pieces, rest[:cut])
+		rest = strings.TrimPrefix(rest[cut:], " ")
+	}
+	return append(pieces, rest)
+}
+
+// prefixWithin returns the byte length of the longest prefix of s that fits in budget code units
+func prefixWithin(s string, budget int) int {
+	n := 0
+	for i, r := range s {
+		width := len(utf16.Encode([]rune{r}))
+		if n+width > budget {
+			return i
+		}
+		n += width
+	}
+	return len(s)
+}
+
+// textLength returns the length in UTF-16 code units
+func textLength(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
@@ -0,0 +1,156 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"go-stock/render"
+)
+
+// stockSection returns a section shaped like one stock in a report
+func stockSection(name string, lines int) render.Document {
+	section := render.Document{render.Line{render.Bold(name)}}
+	for i := 0; i < lines; i++ {
+		section = append(section, render.Line{render.Plain(fmt.Sprintf("Price vs %d-day MA: -0.%02d%%", i, i))})
+	}
+	return section
+}
+
+func TestSplitMessage(t *testing.T) {
+	title := render.Document{render.Line{render.Bold("📊 Large Cap Stocks - 11-Jun-2025")}}
+	var sections []render.Document
+	for i := 0; i < 6; i++ {
+		sections = append(sections, stockSection(fmt.Sprintf("STOCK%d.NS", i), 8))
+	}
+
+	tests := []struct {
+		name     string
+		msg      Message
+		r        render.Renderer
+		limit    int
+		parts    int      // 0 means more than one
+		contains []string // Text that must appear whole in a single part
+	}{
+		{
+			name:  "fits",
+			msg:   Message{Title: title, Sections: sections[:2]},
+			r:     render.MarkdownV2,
+			limit: 4096,
+			parts: 1,
+		},
+		{
+			name:     "between sections",
+			msg:      Message{Title: title, Sections: sections},
+			r:        render.PlainText,
+			limit:    500,
+			contains: []string{"STOCK0.NS", "STOCK3.NS", "STOCK5.NS"},
+		},
+		{
+			// Escaping doubles the dots and dashes, so a split sized on the
+			// plain text would overflow
+			name:  "escaped length",
+			msg:   Message{Title: title, Sections: sections},
+			r:     render.MarkdownV2,
+			limit: 500,
+		},
+		{
+			name:  "long paragraph",
+			msg:   Message{Title: title, Sections: []render.Document{{render.Paragraph(strings.Repeat("RSI is oversold. ", 100))}}},
+			r:     render.HTML,
+			limit: 300,
+		},
+		{
+			name:  "long code block",
+			msg:   Message{Title: title, Sections: []render.Document{{render.Pre(strings.Repeat("`code` line\n", 60))}}},
+			r:     render.MarkdownV2,
+			limit: 300,
+		},
+		{
+			// Each emoji is two UTF-16 code units
+			name:  "emoji",
+			msg:   Message{Title: title, Sections: []render.Document{{render.Paragraph(strings.Repeat("📈📉 ", 200))}}},
+			r:     render.PlainText,
+			limit: 200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := splitMessage(tt.msg, tt.r, tt.limit)
+			if tt.parts != 0 && len(parts) != tt.parts {
+				t.Fatalf("got %d parts, want %d", len(parts), tt.parts)
+			}
+			if tt.parts == 0 && len(parts) < 2 {
+				t.Fatalf("got %d part, want the message split", len(parts))
+			}
+
+			var rendered []string
+			for i, part := range parts {
+				text := tt.r.Render(part)
+				if n := textLength(text); n > tt.limit {
+					t.Errorf("part %d is %d code units, over the limit of %d", i+1, n, tt.limit)
+				}
+				if len(parts) > 1 {
+					header := render.PlainText.Render(partHeader(tt.msg.Title, i+1, len(parts)))
+					if plain := render.PlainText.Render(part); !strings.HasPrefix(plain, header) {
+						t.Errorf("part %d starts %q, want the title and counter", i+1, plain[:min(len(plain), 60)])
+					}
+				}
+				rendered = append(rendered, render.PlainText.Render(part))
+			}
+
+			for _, want := range tt.contains {
+				found := 0
+				for _, text := range rendered {
+					found += strings.Count(text, want)
+				}
+				if found != 1 {
+					t.Errorf("%q appears %d times across the parts, want once", want, found)
+				}
+			}
+		})
+	}
+}
+
+// TestSplitMessageKeepsText checks that a split drops nothing but the
+// whitespace it breaks at
+func TestSplitMessageKeepsText(t *testing.T) {
+	text := strings.Repeat("Support sits at ₹1464.91 and resistance at ₹1477.21. ", 40)
+	msg := Message{Sections: []render.Document{{render.Paragraph(text)}}}
+
+	var got strings.Builder
+	for i, part := range splitMessage(msg, render.MarkdownV2, 400) {
+		body := render.PlainText.Render(part[len(partHeader(nil, i+1, 1)):])
+		got.WriteString(body)
+	}
+	if strip(got.String()) != strip(text) {
+		t.Errorf("split text differs from the original")
+	}
+}
+
+func TestSplitLongLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		budget int
+		want   []string
+	}{
+		{"short", 10, []string{"short"}},
+		{"one two three four", 9, []string{"one two", "three", "four"}},
+		{"abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"📈📈📈", 4, []string{"📈📈", "📈"}},
+		{"anything", 0, []string{"anything"}},
+	}
+
+	for _, tt := range tests {
+		got := splitLongLine(tt.line, tt.budget)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("splitLongLine(%q, %d) = %q, want %q", tt.line, tt.budget, got, tt.want)
+		}
+	}
+}
+
+// strip removes all whitespace
+func strip(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
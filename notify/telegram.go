@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go-stock/render"
+	"go-stock/telegram"
+)
+
+// telegramLimit is the maximum length of a Telegram message
+const telegramLimit = 4096
+
+// Telegram sends messages to one or more chats through a bot
+type Telegram struct {
+	name      string
+	client    *telegram.Client
+	parseMode string
+	chatIDs   []string
+}
+
+// NewTelegram creates a Telegram notifier for the given chats. parseMode is
+// "MarkdownV2" or "HTML".
+func NewTelegram(name, token, parseMode string, chatIDs []string) *Telegram {
+	return &Telegram{name: name, client: telegram.NewClient(token), parseMode: parseMode, chatIDs: chatIDs}
+}
+
+// Name implements Notifier
+func (t *Telegram) Name() string { return t.name }
+
+// Send implements Notifier. A part Telegram cannot parse is resent as plain text.
+func (t *Telegram) Send(msg Message) Delivery {
+	renderer := render.ForParseMode(t.parseMode)
+	if renderer == nil {
+		renderer = render.MarkdownV2
+	}
+
+	parts := splitMessage(msg, renderer, telegramLimit)
+	delivery := Delivery{Notifier: t.name}
+	for _, chatID := range t.chatIDs {
+		for i, part := range parts {
+			result := Result{Target: "chat " + chatID, Part: i + 1, Parts: len(parts)}
+			result.Err = t.client.SendMessage(chatID, renderer.Render(part), t.parseMode)
+			if isFormattingError(result.Err) {
+				result.Note = "sent as plain text"
+				result.Err = t.client.SendMessage(chatID, render.PlainText.Render(part), "")
+			}
+			delivery.Results = append(delivery.Results, result)
+		}
+		delivery.Results = append(delivery.Results, t.sendImages(chatID, msg.Images)...)
+	}
+	return delivery
+}
+
+// sendImages uploads images to a chat as albums of up to telegram.MaxMediaGroup
+func (t *Telegram) sendImages(chatID string, images []Image) []Result {
+	var results []Result
+	for start := 0; start < len(images); start += telegram.MaxMediaGroup {
+		end := start + telegram.MaxMediaGroup
+		if end > len(images) {
+			end = len(images)
+		}
+
+		photos := make([]telegram.Photo, 0, end-start)
+		for _, image := range images[start:end] {
+			photos = append(photos, telegram.Photo{Name: image.Name, Data: image.PNG, Caption: image.Caption})
+		}
+
+		var err error
+		if len(photos) == 1 {
+			err = t.client.SendPhoto(chatID, photos[0])
+		} else {
+			err = t.client.SendMediaGroup(chatID, photos)
+		}
+		results = append(results, Result{Target: fmt.Sprintf("chat %s (%d chart(s))", chatID, len(photos)), Err: err})
+	}
+	return results
+}
+
+// isFormattingError reports whether Telegram rejected a message's markup
+func isFormattingError(err error) bool {
+	var apiErr *telegram.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == 400 &&
+		strings.Contains(apiErr.Description, "can't parse entities")
+}
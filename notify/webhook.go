@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go-stock/render"
+	"go-stock/replay"
+)
+
+// Webhook POSTs every message as JSON to a URL
+type Webhook struct {
+	name    string
+	url     string
+	headers map[string]string
+}
+
+// NewWebhook creates a generic JSON webhook notifier
+func NewWebhook(name, url string, headers map[string]string) (*Webhook, error) {
+	if url == "" {
+		return nil, fmt.Errorf("webhook url not set")
+	}
+	return &Webhook{name: name, url: url, headers: headers}, nil
+}
+
+// Name implements Notifier
+func (w *Webhook) Name() string { return w.name }
+
+// Send implements Notifier
+func (w *Webhook) Send(msg Message) Delivery {
+	sections := make([]string, len(msg.Sections))
+	for i, section := range msg.Sections {
+		sections[i] = render.PlainText.Render(section)
+	}
+	payload := map[string]interface{}{
+		"title":    render.PlainText.Render(msg.Title),
+		"sections": sections,
+		"text":     msg.Text(),
+		"html":     render.HTML.Render(msg.Document()),
+	}
+	err := postJSON(w.url, payload, w.headers)
+	return Delivery{Notifier: w.name, Results: []Result{{Target: "webhook", Err: err}}}
+}
+
+// postJSON sends payload to url and treats any non-2xx status as an error
+func postJSON(url string, payload interface{}, headers map[string]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := replay.Client(30 * time.Second).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	return nil
+}
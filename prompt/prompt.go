@@ -0,0 +1,134 @@
+// Package prompt renders the prompts sent to the model from text/template
+// files. The bundled templates can be replaced one by one from a directory.
+package prompt
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"go-stock/config"
+)
+
+//go:embed templates/*.tmpl
+var bundled embed.FS
+
+// Names of the prompts the analysis renders
+const (
+	Stock = "stock" // One stock, see stock.tmpl
+	Batch = "batch" // A group of stocks in one request, see batch.tmpl
+)
+
+// Prompt is a rendered prompt and the version of the templates that produced it
+type Prompt struct {
+	Text    string
+	Version string // e.g. "stock@1a2b3c4d", changes whenever any template changes
+}
+
+// Set is a loaded collection of templates. Templates can include each other
+// by name, e.g. {{template "details" .}} for details.tmpl.
+type Set struct {
+	root *template.Template
+	hash string // Hash of every template source, so edits to shared parts change the version
+}
+
+// funcs are available to every template
+var funcs = template.FuncMap{
+	"rupees": func(v float64) string { return fmt.Sprintf("₹%.2f", v) },
+	"pct":    func(v float64) string { return fmt.Sprintf("%.2f%%", v) },
+	"num":    func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"lower":  strings.ToLower,
+	"upper":  strings.ToUpper,
+}
+
+// Load returns the bundled templates, with any *.tmpl file in dir replacing
+// or adding the template of the same name. An empty dir uses only the
+// bundled templates.
+func Load(dir string) (*Set, error) {
+	sources := make(map[string]string)
+	bundledFiles, err := fs.Glob(bundled, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range bundledFiles {
+		data, err := bundled.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources[templateName(file)] = string(data)
+	}
+
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			if _, err := os.Stat(dir); err != nil {
+				return nil, fmt.Errorf("failed to read prompt directory: %v", err)
+			}
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read prompt template: %v", err)
+			}
+			sources[templateName(file)] = string(data)
+		}
+	}
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	root := template.New("").Funcs(funcs).Option("missingkey=error")
+	hash := sha256.New()
+	for _, name := range names {
+		if _, err := root.New(name).Parse(sources[name]); err != nil {
+			return nil, fmt.Errorf("invalid prompt template %s: %v", name, err)
+		}
+		fmt.Fprintf(hash, "%s\x00%s\x00", name, sources[name])
+	}
+	return &Set{root: root, hash: hex.EncodeToString(hash.Sum(nil))[:8]}, nil
+}
+
+// Default returns the templates from PROMPT_DIR, falling back to the bundled
+// templates if that directory cannot be loaded
+func Default() *Set {
+	set, err := Load(config.GetConfig().PromptDir)
+	if err != nil {
+		fmt.Printf("Warning: %v, using bundled prompts\n", err)
+		set, err = Load("")
+		if err != nil {
+			panic("bundled prompt templates are invalid: " + err.Error())
+		}
+	}
+	return set
+}
+
+// Render executes the named template with data
+func (s *Set) Render(name string, data interface{}) (Prompt, error) {
+	if s.root.Lookup(name) == nil {
+		return Prompt{}, fmt.Errorf("no prompt template named %s", name)
+	}
+	var sb strings.Builder
+	if err := s.root.ExecuteTemplate(&sb, name, data); err != nil {
+		return Prompt{}, fmt.Errorf("rendering prompt %s: %v", name, err)
+	}
+	return Prompt{Text: sb.String(), Version: name + "@" + s.hash}, nil
+}
+
+// templateName is the file name without directory and .tmpl extension
+func templateName(file string) string {
+	return strings.TrimSuffix(path.Base(filepath.ToSlash(file)), ".tmpl")
+}
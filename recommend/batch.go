@@ -0,0 +1,74 @@
+package recommend
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// BatchSchema asks for one recommendation per stock, each tagged with its symbol
+var BatchSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"recommendations": map[string]interface{}{
+			"type":  "array",
+			"items": batchItemSchema(),
+		},
+	},
+	"required": []string{"recommendations"},
+}
+
+// batchItemSchema is Schema with a symbol field added
+func batchItemSchema() map[string]interface{} {
+	properties := map[string]interface{}{
+		"symbol": map[string]interface{}{"type": "string", "description": "Stock symbol exactly as given, e.g. TCS.NS"},
+	}
+	for name, property := range Schema["properties"].(map[string]interface{}) {
+		properties[name] = property
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   append([]string{"symbol"}, Schema["required"].([]string)...),
+	}
+}
+
+// BatchInstructions ask for the batch JSON object in the prompt itself
+const BatchInstructions = "Respond with only a JSON object, no other text, holding a \"recommendations\" list " +
+	"with one entry per stock. Each entry has these fields:\n" +
+	"- symbol: the stock symbol exactly as given above\n" +
+	fieldList +
+	"Be direct and decisive, and judge each stock on its own numbers."
+
+// ParseBatch decodes a batch response into recommendations keyed by
+// upper-cased symbol. Entries without a symbol are dropped; checking that
+// every stock is present and valid is left to the caller.
+func ParseBatch(text string) (map[string]Recommendation, error) {
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON object in model response")
+	}
+
+	var batch struct {
+		Recommendations []struct {
+			Symbol string `json:"symbol"`
+			Recommendation
+		} `json:"recommendations"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &batch); err != nil {
+		return nil, fmt.Errorf("invalid batch JSON: %v", err)
+	}
+
+	recs := make(map[string]Recommendation, len(batch.Recommendations))
+	for _, item := range batch.Recommendations {
+		symbol := strings.ToUpper(strings.TrimSpace(item.Symbol))
+		if symbol == "" {
+			continue
+		}
+		recs[symbol] = item.Recommendation.normalize()
+	}
+	if len(recs) == 0 {
+		return nil, fmt.Errorf("no recommendations in batch response")
+	}
+	return recs, nil
+}
@@ -0,0 +1,169 @@
+// Package recommend parses, validates and renders the structured trading
+// recommendation requested from the model.
+package recommend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Actions and risk levels a recommendation may use
+var (
+	Actions    = []string{"BUY", "SELL", "HOLD"}
+	RiskLevels = []string{"Low", "Medium", "High"}
+)
+
+// Validation tolerances relative to the current price
+const (
+	maxEntryDistance = 0.10 // Entry must be within 10% of the current price
+	levelTolerance   = 0.02 // Support may sit up to 2% above the price, resistance 2% below
+)
+
+// Recommendation is the model's trading call for one stock
+type Recommendation struct {
+	Action     string    `json:"action"` // BUY, SELL or HOLD
+	Entry      float64   `json:"entry"`
+	StopLoss   float64   `json:"stop_loss"`
+	Targets    []float64 `json:"targets"`
+	Support    []float64 `json:"support"`
+	Resistance []float64 `json:"resistance"`
+	Risk       string    `json:"risk"` // Low, Medium or High
+	Rationale  string    `json:"rationale"`
+}
+
+// Schema is the JSON schema the model is asked to follow
+var Schema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"action":     map[string]interface{}{"type": "string", "enum": Actions},
+		"entry":      map[string]interface{}{"type": "number", "description": "Entry price in rupees"},
+		"stop_loss":  map[string]interface{}{"type": "number", "description": "Stop-loss price in rupees"},
+		"targets":    numberList("Target prices in rupees, nearest first"),
+		"support":    numberList("Key support levels in rupees"),
+		"resistance": numberList("Key resistance levels in rupees"),
+		"risk":       map[string]interface{}{"type": "string", "enum": RiskLevels},
+		"rationale":  map[string]interface{}{"type": "string", "description": "Two or three sentences explaining the call"},
+	},
+	"required": []string{"action", "entry", "stop_loss", "targets", "support", "resistance", "risk", "rationale"},
+}
+
+func numberList(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"items":       map[string]interface{}{"type": "number"},
+		"description": description,
+	}
+}
+
+// fieldList describes each field of a recommendation for the instructions
+const fieldList = "- action: BUY, SELL or HOLD\n" +
+	"- entry: entry price in rupees\n" +
+	"- stop_loss: stop-loss price in rupees (below entry for BUY, above entry for SELL)\n" +
+	"- targets: list of target prices in rupees, nearest first\n" +
+	"- support: list of key support levels below the current price\n" +
+	"- resistance: list of key resistance levels above the current price\n" +
+	"- risk: Low, Medium or High\n" +
+	"- rationale: two or three sentences explaining the call\n"
+
+// Instructions ask for the JSON object in the prompt itself, for models
+// that ignore the schema
+const Instructions = "Respond with only a JSON object, no other text, with these fields:\n" +
+	fieldList +
+	"Be direct and decisive."
+
+// Parse decodes a recommendation from model output, tolerating Markdown
+// code fences or text around the JSON object, and normalises its enums
+func Parse(text string) (Recommendation, error) {
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return Recommendation{}, fmt.Errorf("no JSON object in model response")
+	}
+
+	var rec Recommendation
+	if err := json.Unmarshal([]byte(text[start:end+1]), &rec); err != nil {
+		return Recommendation{}, fmt.Errorf("invalid recommendation JSON: %v", err)
+	}
+	return rec.normalize(), nil
+}
+
+// normalize upper-cases the action, capitalises the risk level and trims the rationale
+func (r Recommendation) normalize() Recommendation {
+	r.Action = strings.ToUpper(strings.TrimSpace(r.Action))
+	if risk := strings.TrimSpace(r.Risk); risk != "" {
+		r.Risk = strings.ToUpper(risk[:1]) + strings.ToLower(risk[1:])
+	}
+	r.Rationale = strings.TrimSpace(r.Rationale)
+	return r
+}
+
+// Validate checks the recommendation is complete and consistent with the
+// current price: a BUY needs its stop-loss below the entry and the price and
+// its targets above the entry (a SELL the reverse), the entry must be near
+// the price, and support and resistance must sit on the right side of it
+func (r Recommendation) Validate(price float64) error {
+	var problems []error
+	if !contains(Actions, r.Action) {
+		problems = append(problems, fmt.Errorf("unknown action %q", r.Action))
+	}
+	if !contains(RiskLevels, r.Risk) {
+		problems = append(problems, fmt.Errorf("unknown risk level %q", r.Risk))
+	}
+	if r.Rationale == "" {
+		problems = append(problems, fmt.Errorf("missing rationale"))
+	}
+
+	if r.Action == "BUY" || r.Action == "SELL" {
+		if r.Entry <= 0 || r.StopLoss <= 0 || len(r.Targets) == 0 {
+			problems = append(problems, fmt.Errorf("%s needs entry, stop_loss and targets", r.Action))
+		} else {
+			if price > 0 && abs(r.Entry-price)/price > maxEntryDistance {
+				problems = append(problems, fmt.Errorf("entry ₹%.2f is more than %.0f%% from the price ₹%.2f", r.Entry, maxEntryDistance*100, price))
+			}
+			// below reports whether a level belongs below the entry for this action
+			below := func(level float64) bool { return (level < r.Entry) == (r.Action == "BUY") }
+			if !below(r.StopLoss) || r.StopLoss == r.Entry {
+				problems = append(problems, fmt.Errorf("stop-loss ₹%.2f is on the wrong side of entry ₹%.2f for %s", r.StopLoss, r.Entry, r.Action))
+			}
+			if price > 0 && (r.StopLoss == price || (r.StopLoss < price) != (r.Action == "BUY")) {
+				problems = append(problems, fmt.Errorf("stop-loss ₹%.2f is on the wrong side of the price ₹%.2f for %s", r.StopLoss, price, r.Action))
+			}
+			for _, target := range r.Targets {
+				if below(target) || target == r.Entry {
+					problems = append(problems, fmt.Errorf("target ₹%.2f is on the wrong side of entry ₹%.2f for %s", target, r.Entry, r.Action))
+				}
+			}
+		}
+	}
+
+	if price > 0 {
+		for _, level := range r.Support {
+			if level > price*(1+levelTolerance) {
+				problems = append(problems, fmt.Errorf("support ₹%.2f is above the price ₹%.2f", level, price))
+			}
+		}
+		for _, level := range r.Resistance {
+			if level < price*(1-levelTolerance) {
+				problems = append(problems, fmt.Errorf("resistance ₹%.2f is below the price ₹%.2f", level, price))
+			}
+		}
+	}
+	return errors.Join(problems...)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
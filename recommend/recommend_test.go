@@ -0,0 +1,176 @@
+package recommend
+
+import (
+	"strings"
+	"testing"
+)
+
+// buy is a valid BUY call at a price of 100
+func buy() Recommendation {
+	return Recommendation{
+		Action:     "BUY",
+		Entry:      100,
+		StopLoss:   95,
+		Targets:    []float64{105, 110},
+		Support:    []float64{97},
+		Resistance: []float64{104},
+		Risk:       "Medium",
+		Rationale:  "Holding above the 20-day average.",
+	}
+}
+
+// sell is a valid SELL call at a price of 100
+func sell() Recommendation {
+	return Recommendation{
+		Action:     "SELL",
+		Entry:      100,
+		StopLoss:   105,
+		Targets:    []float64{95, 90},
+		Support:    []float64{96},
+		Resistance: []float64{103},
+		Risk:       "High",
+		Rationale:  "Rejected at resistance.",
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		base   func() Recommendation
+		change func(r *Recommendation)
+		price  float64
+		want   []string // Substrings of the error, none for a valid call
+	}{
+		{name: "valid BUY", base: buy, price: 100},
+		{name: "valid SELL", base: sell, price: 100},
+		{name: "valid HOLD", price: 100, base: func() Recommendation {
+			return Recommendation{Action: "HOLD", Risk: "Low", Rationale: "Wait."}
+		}},
+		{name: "entry near the price", base: buy, price: 104},
+		{name: "support just above the price", base: buy, price: 100, change: func(r *Recommendation) {
+			r.Support = []float64{101.5}
+		}},
+		{name: "unknown action", base: buy, price: 100, want: []string{`unknown action "buy"`}, change: func(r *Recommendation) {
+			r.Action = "buy"
+		}},
+		{name: "unknown risk", base: buy, price: 100, want: []string{`unknown risk level "Extreme"`}, change: func(r *Recommendation) {
+			r.Risk = "Extreme"
+		}},
+		{name: "missing rationale", base: buy, price: 100, want: []string{"missing rationale"}, change: func(r *Recommendation) {
+			r.Rationale = ""
+		}},
+		{name: "missing targets", base: sell, price: 100, want: []string{"SELL needs entry, stop_loss and targets"}, change: func(r *Recommendation) {
+			r.Targets = nil
+		}},
+		{name: "entry far from the price", base: buy, price: 120, want: []string{"entry ₹100.00 is more than 10% from the price ₹120.00"}, change: func(r *Recommendation) {
+			r.Resistance = nil
+		}},
+		{name: "BUY stop-loss above entry", base: buy, price: 100, want: []string{"stop-loss ₹101.00 is on the wrong side of entry ₹100.00 for BUY"}, change: func(r *Recommendation) {
+			r.StopLoss = 101
+		}},
+		{name: "SELL stop-loss below entry", base: sell, price: 100, want: []string{"stop-loss ₹99.00 is on the wrong side of entry ₹100.00 for SELL"}, change: func(r *Recommendation) {
+			r.StopLoss = 99
+		}},
+		{name: "stop-loss at entry", base: buy, price: 101, want: []string{"stop-loss ₹100.00 is on the wrong side of entry"}, change: func(r *Recommendation) {
+			r.StopLoss = 100
+		}},
+		{name: "BUY stop-loss above the price", base: buy, price: 94, want: []string{"stop-loss ₹95.00 is on the wrong side of the price ₹94.00 for BUY"}, change: func(r *Recommendation) {
+			r.Support = nil
+		}},
+		{name: "SELL stop-loss at the price", base: sell, price: 105, want: []string{"stop-loss ₹105.00 is on the wrong side of the price ₹105.00 for SELL"}, change: func(r *Recommendation) {
+			r.Support = nil
+		}},
+		{name: "BUY target below entry", base: buy, price: 100, want: []string{"target ₹98.00 is on the wrong side of entry ₹100.00 for BUY"}, change: func(r *Recommendation) {
+			r.Targets = []float64{98, 110}
+		}},
+		{name: "SELL target at entry", base: sell, price: 100, want: []string{"target ₹100.00 is on the wrong side of entry ₹100.00 for SELL"}, change: func(r *Recommendation) {
+			r.Targets = []float64{100}
+		}},
+		{name: "support above the price", base: buy, price: 100, want: []string{"support ₹103.00 is above the price ₹100.00"}, change: func(r *Recommendation) {
+			r.Support = []float64{97, 103}
+		}},
+		{name: "resistance below the price", base: sell, price: 100, want: []string{"resistance ₹97.00 is below the price ₹100.00"}, change: func(r *Recommendation) {
+			r.Resistance = []float64{97}
+		}},
+		{name: "no price skips the price checks", base: buy, price: 0, change: func(r *Recommendation) {
+			r.Support = []float64{500}
+			r.Resistance = []float64{1}
+		}},
+		{name: "every problem reported", base: buy, price: 100, want: []string{"unknown risk level", "missing rationale", "target ₹90.00"}, change: func(r *Recommendation) {
+			r.Risk, r.Rationale, r.Targets = "", "", []float64{90}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := tt.base()
+			if tt.change != nil {
+				tt.change(&rec)
+			}
+			err := rec.Validate(tt.price)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Validate() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() = nil, want an error containing %q", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    Recommendation
+		wantErr string
+	}{
+		{
+			name: "plain JSON",
+			text: `{"action":"BUY","entry":100,"stop_loss":95,"targets":[105],"risk":"Medium","rationale":"Up."}`,
+			want: Recommendation{Action: "BUY", Entry: 100, StopLoss: 95, Targets: []float64{105}, Risk: "Medium", Rationale: "Up."},
+		},
+		{
+			name: "code fence and normalising",
+			text: "Here you go:\n```json\n{\"action\":\" sell\",\"risk\":\"HIGH\",\"rationale\":\"  Down.  \"}\n```",
+			want: Recommendation{Action: "SELL", Risk: "High", Rationale: "Down."},
+		},
+		{
+			name:    "no JSON",
+			text:    "I cannot help with that.",
+			wantErr: "no JSON object in model response",
+		},
+		{
+			name:    "invalid JSON",
+			text:    `{"action": BUY}`,
+			wantErr: "invalid recommendation JSON",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.text)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got.Action != tt.want.Action || got.Risk != tt.want.Risk || got.Rationale != tt.want.Rationale ||
+				got.Entry != tt.want.Entry || got.StopLoss != tt.want.StopLoss || len(got.Targets) != len(tt.want.Targets) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package recommend
+
+import (
+	"fmt"
+	"strings"
+
+	"go-stock/render"
+)
+
+// actionEmoji marks each action in the card
+var actionEmoji = map[string]string{
+	"BUY":  "🟢",
+	"SELL": "🔴",
+	"HOLD": "🟡",
+}
+
+// Render returns the recommendation as card lines, with each level's
+// distance from the current price
+func (r Recommendation) Render(price float64) render.Document {
+	doc := render.Document{
+		render.Line{
+			render.Plain(actionEmoji[r.Action] + " "), render.Bold(r.Action),
+			render.Plain(" · Risk: "), render.Bold(r.Risk),
+		},
+	}
+
+	if r.Entry > 0 || r.StopLoss > 0 {
+		line := render.Line{render.Plain("Entry: " + levels(price, r.Entry))}
+		if r.StopLoss > 0 {
+			line = append(line, render.Plain(" · Stop-loss: "+levels(price, r.StopLoss)))
+		}
+		doc = append(doc, line)
+	}
+	if len(r.Targets) > 0 {
+		doc = append(doc, render.Line{render.Plain("Targets: " + levels(price, r.Targets...))})
+	}
+	if len(r.Support) > 0 || len(r.Resistance) > 0 {
+		doc = append(doc, render.Line{render.Plain(fmt.Sprintf("Support: %s · Resistance: %s", plainLevels(r.Support), plainLevels(r.Resistance)))})
+	}
+	if r.Rationale != "" {
+		doc = append(doc, render.Paragraph(render.SanitizeLLM(r.Rationale)))
+	}
+	return doc
+}
+
+// levels formats prices with their percentage distance from price, e.g. "₹1250.00 (+2.1%)"
+func levels(price float64, values ...float64) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("₹%.2f", v)
+		if price > 0 && v != price {
+			parts[i] += fmt.Sprintf(" (%+.1f%%)", (v-price)/price*100)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// plainLevels formats prices without distances, or "-" if there are none
+func plainLevels(values []float64) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("₹%.2f", v)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,101 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+)
+
+// markdownV2Special are the characters Telegram MarkdownV2 requires to be
+// escaped outside entities
+const markdownV2Special = "_*[]()~`>#+-=|{}.!\\"
+
+// escapeMarkdownV2 escapes text for use outside MarkdownV2 entities
+func escapeMarkdownV2(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(markdownV2Special, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// escapeMarkdownV2Code escapes text inside MarkdownV2 code spans and blocks,
+// where only ` and \ are special
+func escapeMarkdownV2Code(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(text)
+}
+
+// escapeHTML escapes the three characters Telegram's HTML mode requires
+func escapeHTML(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// slackFormatting are the mrkdwn formatting characters. Slack has no
+// escape for them, so they are fenced with zero-width spaces, which stop
+// them pairing up while showing nothing.
+var slackFormatting = strings.NewReplacer(
+	"*", "\u200b*\u200b",
+	"_", "\u200b_\u200b",
+	"~", "\u200b~\u200b",
+	"`", "\u200b`\u200b",
+)
+
+// escapeSlack escapes the control characters of Slack mrkdwn and
+// neutralises its formatting characters
+func escapeSlack(text string) string {
+	return slackFormatting.Replace(escapeHTML(text))
+}
+
+// escapeSlackCode escapes text inside Slack code spans and blocks, where
+// formatting does not apply but a fence could still close the block early
+func escapeSlackCode(text string) string {
+	return escapeCodeFence(escapeHTML(text))
+}
+
+// escapeDiscord backslash-escapes Discord Markdown characters
+func escapeDiscord(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune("\\*_~`|>#", r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// escapeCodeFence stops text from closing a Markdown code block early
+func escapeCodeFence(text string) string {
+	return strings.ReplaceAll(text, "```", "`\u200b``")
+}
+
+func noEscape(text string) string { return text }
+
+var (
+	llmHeading  = regexp.MustCompile(`(?m)^[ \t]*#{1,6}[ \t]+`)
+	llmBullet   = regexp.MustCompile(`(?m)^([ \t]*)[*+-][ \t]+`)
+	llmEmphasis = regexp.MustCompile(`\*\*|__|\x60+`)
+	llmLink     = regexp.MustCompile(`\[([^\]\n]+)\]\((https?://[^)\s]+)\)`)
+	llmBlank    = regexp.MustCompile(`\n{3,}`)
+)
+
+// SanitizeLLM turns model output into plain text for a Paragraph: Markdown
+// headings, bold markers and backticks are dropped, bullets become "•" and
+// links keep their text and URL. Control characters are removed.
+func SanitizeLLM(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, text)
+	text = llmLink.ReplaceAllString(text, "$1 ($2)")
+	text = llmHeading.ReplaceAllString(text, "")
+	text = llmBullet.ReplaceAllString(text, "$1• ")
+	text = llmEmphasis.ReplaceAllString(text, "")
+	text = llmBlank.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
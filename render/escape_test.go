@@ -0,0 +1,91 @@
+package render
+
+import "testing"
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		name   string
+		escape func(string) string
+		in     string
+		want   string
+	}{
+		{"MarkdownV2 price", escapeMarkdownV2, "₹1,474.50 (-0.35%)", `₹1,474\.50 \(\-0\.35%\)`},
+		{"MarkdownV2 every special", escapeMarkdownV2, "_*[]()~`>#+-=|{}.!\\", "\\_\\*\\[\\]\\(\\)\\~\\`\\>\\#\\+\\-\\=\\|\\{\\}\\.\\!\\\\"},
+		{"MarkdownV2 plain", escapeMarkdownV2, "RSI 61", "RSI 61"},
+		{"MarkdownV2 code", escapeMarkdownV2Code, "a`b\\c.d", "a\\`b\\\\c.d"},
+		{"HTML", escapeHTML, "<b>P&L</b> > 0", "&lt;b&gt;P&amp;L&lt;/b&gt; &gt; 0"},
+		{"HTML leaves quotes", escapeHTML, `"stop_loss"`, `"stop_loss"`},
+		{"Slack control", escapeSlack, "<!channel> & more", "&lt;!channel&gt; &amp; more"},
+		{"Slack formatting", escapeSlack, "*up* _down_", "\u200b*\u200bup\u200b*\u200b \u200b_\u200bdown\u200b_\u200b"},
+		{"Slack strike and code", escapeSlack, "~x~ `y`", "\u200b~\u200bx\u200b~\u200b \u200b`\u200by\u200b`\u200b"},
+		{"Slack code keeps formatting", escapeSlackCode, "*a* <b>", "*a* &lt;b&gt;"},
+		{"Slack code fence", escapeSlackCode, "```", "`\u200b``"},
+		{"Discord", escapeDiscord, "*bold* _it_ ~s~ `c` |sp| > q # h \\", "\\*bold\\* \\_it\\_ \\~s\\~ \\`c\\` \\|sp\\| \\> q \\# h \\\\"},
+		{"Discord leaves punctuation", escapeDiscord, "₹3,686.00 (+1.5%)", "₹3,686.00 (+1.5%)"},
+		{"code fence", escapeCodeFence, "a ``` b ``` c", "a `\u200b`` b `\u200b`` c"},
+		{"code fence short runs", escapeCodeFence, "`a` ``b``", "`a` ``b``"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.escape(tt.in); got != tt.want {
+				t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	doc := Document{
+		Line{Bold("TCS (TCS.NS)"), Plain(" 1.5%")},
+		Line{Italic("a_b"), Code("x`y")},
+		Paragraph("P&L < 0."),
+		Rule{},
+		Pre("line 1\n```"),
+		Line{Bold(""), Plain("end")},
+	}
+
+	tests := []struct {
+		name string
+		r    Renderer
+		want string
+	}{
+		{"MarkdownV2", MarkdownV2, "*TCS \\(TCS\\.NS\\)* 1\\.5%\n_a\\_b_`x\\`y`\nP&L < 0\\.\n\\-\\-\\-\n```\nline 1\n\\`\\`\\`\n```\nend"},
+		{"HTML", HTML, "<b>TCS (TCS.NS)</b> 1.5%\n<i>a_b</i><code>x`y</code>\nP&amp;L &lt; 0.\n---\n<pre>line 1\n```</pre>\nend"},
+		{"Slack", Slack, "*TCS (TCS.NS)* 1.5%\n_a\u200b_\u200bb_`x`y`\nP&amp;L &lt; 0.\n---\n```\nline 1\n`\u200b``\n```\nend"},
+		{"Discord", Discord, "**TCS (TCS.NS)** 1.5%\n*a\\_b*`x`y`\nP&L < 0.\n---\n```\nline 1\n`\u200b``\n```\nend"},
+		{"plain text", PlainText, "TCS (TCS.NS) 1.5%\na_bx`y\nP&L < 0.\n---\nline 1\n```\nend"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Render(doc); got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeLLM(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"heading", "## Outlook\nHold", "Outlook\nHold"},
+		{"emphasis", "**Buy** above `3712`", "Buy above 3712"},
+		{"bullets", "* one\n- two\n  + three", "• one\n• two\n  • three"},
+		{"link", "See [NSE](https://www.nseindia.com) now", "See NSE (https://www.nseindia.com) now"},
+		{"blank lines", "a\r\n\r\n\r\n\r\nb", "a\n\nb"},
+		{"control characters", "a\x00b\x1bc\td", "abc\td"},
+		{"keeps single markers", "stop_loss *uptrend*", "stop_loss *uptrend*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeLLM(tt.in); got != tt.want {
+				t.Errorf("SanitizeLLM(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+// Package render builds reports as structured documents and renders them
+// for each chat backend with the escaping that backend needs.
+package render
+
+import "strings"
+
+// Style is how an inline span is formatted
+type Style int
+
+const (
+	StylePlain Style = iota
+	StyleBold
+	StyleItalic
+	StyleCode
+)
+
+// Span is a run of text with one style
+type Span struct {
+	Text  string
+	Style Style
+}
+
+// Plain returns an unformatted span
+func Plain(text string) Span { return Span{Text: text} }
+
+// Bold returns a bold span
+func Bold(text string) Span { return Span{Text: text, Style: StyleBold} }
+
+// Italic returns an italic span
+func Italic(text string) Span { return Span{Text: text, Style: StyleItalic} }
+
+// Code returns an inline code span
+func Code(text string) Span { return Span{Text: text, Style: StyleCode} }
+
+// Block is one element of a document, rendered on its own line(s)
+type Block interface {
+	block()
+}
+
+// Line is a single line of inline spans
+type Line []Span
+
+// Paragraph is free text, e.g. LLM output, shown as-is. It may span several
+// lines and is always escaped, so it can never break the message markup.
+type Paragraph string
+
+// Pre is a preformatted code block
+type Pre string
+
+// Rule separates parts of a document
+type Rule struct{}
+
+func (Line) block()      {}
+func (Paragraph) block() {}
+func (Pre) block()       {}
+func (Rule) block()      {}
+
+// Document is a list of blocks
+type Document []Block
+
+// Text returns a document holding a single paragraph
+func Text(text string) Document {
+	if text == "" {
+		return nil
+	}
+	return Document{Paragraph(text)}
+}
+
+// Renderer turns a document into the markup of one backend
+type Renderer interface {
+	Render(doc Document) string
+}
+
+// Renderers for each supported markup
+var (
+	MarkdownV2 Renderer = markup{
+		escape:     escapeMarkdownV2,
+		escapeCode: escapeMarkdownV2Code,
+		bold:       [2]string{"*", "*"},
+		italic:     [2]string{"_", "_"},
+		code:       [2]string{"`", "`"},
+		pre:        [2]string{"```\n", "\n```"},
+		rule:       `\-\-\-`,
+	}
+	HTML Renderer = markup{
+		escape:     escapeHTML,
+		escapeCode: escapeHTML,
+		bold:       [2]string{"<b>", "</b>"},
+		italic:     [2]string{"<i>", "</i>"},
+		code:       [2]string{"<code>", "</code>"},
+		pre:        [2]string{"<pre>", "</pre>"},
+		rule:       "---",
+	}
+	Slack Renderer = markup{
+		escape:     escapeSlack,
+		escapeCode: escapeSlackCode,
+		bold:       [2]string{"*", "*"},
+		italic:     [2]string{"_", "_"},
+		code:       [2]string{"`", "`"},
+		pre:        [2]string{"```\n", "\n```"},
+		rule:       "---",
+	}
+	Discord Renderer = markup{
+		escape:     escapeDiscord,
+		escapeCode: escapeCodeFence,
+		bold:       [2]string{"**", "**"},
+		italic:     [2]string{"*", "*"},
+		code:       [2]string{"`", "`"},
+		pre:        [2]string{"```\n", "\n```"},
+		rule:       "---",
+	}
+	PlainText Renderer = markup{
+		escape:     noEscape,
+		escapeCode: noEscape,
+		rule:       "---",
+	}
+)
+
+// markup renders documents by wrapping spans in per-style delimiters
+type markup struct {
+	escape     func(string) string // Escapes ordinary text
+	escapeCode func(string) string // Escapes text inside code spans and blocks
+	bold       [2]string
+	italic     [2]string
+	code       [2]string
+	pre        [2]string
+	rule       string
+}
+
+// Render implements Renderer
+func (m markup) Render(doc Document) string {
+	lines := make([]string, 0, len(doc))
+	for _, b := range doc {
+		switch b := b.(type) {
+		case Line:
+			var sb strings.Builder
+			for _, span := range b {
+				sb.WriteString(m.span(span))
+			}
+			lines = append(lines, sb.String())
+		case Paragraph:
+			lines = append(lines, m.escape(string(b)))
+		case Pre:
+			lines = append(lines, m.pre[0]+m.escapeCode(string(b))+m.pre[1])
+		case Rule:
+			lines = append(lines, m.rule)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// span renders one inline span. Empty spans render as nothing, since an
+// empty entity is a parse error in Telegram.
+func (m markup) span(s Span) string {
+	if s.Text == "" {
+		return ""
+	}
+	switch s.Style {
+	case StyleBold:
+		return m.bold[0] + m.escape(s.Text) + m.bold[1]
+	case StyleItalic:
+		return m.italic[0] + m.escape(s.Text) + m.italic[1]
+	case StyleCode:
+		return m.code[0] + m.escapeCode(s.Text) + m.code[1]
+	default:
+		return m.escape(s.Text)
+	}
+}
+
+// ForParseMode returns the renderer for a Telegram parse_mode, or nil if unknown
+func ForParseMode(parseMode string) Renderer {
+	switch parseMode {
+	case "MarkdownV2":
+		return MarkdownV2
+	case "HTML":
+		return HTML
+	case "":
+		return PlainText
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,290 @@
+// Package replay records outbound HTTP traffic to a fixture directory and
+// serves it back deterministically, so runs can be reproduced offline.
+//
+// The mode is chosen with the HTTP_MODE environment variable:
+//   - unset or "live": requests go to the network untouched
+//   - "record": requests go to the network and every response is saved
+//   - "replay": responses are served from fixtures and the network is never used
+//
+// Fixtures live in HTTP_FIXTURE_DIR (default "fixtures"). Secrets such as
+// the Telegram bot token, API keys and Slack and Discord webhook paths are
+// redacted before anything is saved, and only the Content-Type and
+// Retry-After response headers are kept.
+package replay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go-stock/config"
+)
+
+// Mode values for HTTP_MODE
+const (
+	ModeLive   = "live"
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+// Fixture is a single recorded request/response pair
+type Fixture struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+		Body   string `json:"body,omitempty"`
+	} `json:"request"`
+	Response struct {
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header"`
+		Body       string      `json:"body"`
+	} `json:"response"`
+}
+
+// session is stored alongside the fixtures so replays see the same clock
+type session struct {
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// recorder is an http.RoundTripper that records or replays fixtures
+type recorder struct {
+	mode  string
+	dir   string
+	next  http.RoundTripper
+	start time.Time
+
+	mu       sync.Mutex
+	sequence map[string]int
+}
+
+var (
+	once   sync.Once
+	active *recorder
+)
+
+// current returns the process-wide recorder, configured on first use
+func current() *recorder {
+	once.Do(func() {
+		cfg := config.GetConfig()
+		active = &recorder{
+			mode:     cfg.HTTPMode,
+			dir:      cfg.FixtureDir,
+			next:     http.DefaultTransport,
+			start:    time.Now(),
+			sequence: make(map[string]int),
+		}
+
+		switch active.mode {
+		case ModeRecord:
+			if err := active.saveSession(); err != nil {
+				fmt.Printf("Warning: could not initialise fixture directory %s: %v\n", active.dir, err)
+			}
+		case ModeReplay:
+			if err := active.loadSession(); err != nil {
+				fmt.Printf("Warning: could not load replay session from %s: %v\n", active.dir, err)
+			}
+		}
+	})
+	return active
+}
+
+// Mode returns the active HTTP mode
+func Mode() string {
+	return current().mode
+}
+
+// Transport returns the round tripper every outbound HTTP client should use
+func Transport() http.RoundTripper {
+	r := current()
+	if r.mode == ModeLive {
+		return r.next
+	}
+	return r
+}
+
+// Client returns an HTTP client that goes through Transport
+func Client(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: Transport(), Timeout: timeout}
+}
+
+// Now returns the current time, or the time the fixtures were recorded when
+// replaying, so date-dependent requests produce identical URLs
+func Now() time.Time {
+	r := current()
+	if r.mode == ModeLive {
+		return time.Now()
+	}
+	return r.start
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	url := Redact(req.URL.String())
+	key := fixtureKey(req, url, body)
+
+	r.mu.Lock()
+	seq := r.sequence[key]
+	r.sequence[key] = seq + 1
+	r.mu.Unlock()
+
+	path := filepath.Join(r.dir, fmt.Sprintf("%s-%03d.json", key, seq))
+
+	if r.mode == ModeReplay {
+		return r.replay(req, path)
+	}
+	return r.record(req, path, url, body)
+}
+
+// record performs the request and saves the response as a fixture
+func (r *recorder) record(req *http.Request, path, url string, body []byte) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	var fixture Fixture
+	fixture.Request.Method = req.Method
+	fixture.Request.URL = url
+	if !isMultipart(req) {
+		fixture.Request.Body = Redact(string(body))
+	}
+	fixture.Response.StatusCode = resp.StatusCode
+	fixture.Response.Header = savedHeaders(resp.Header)
+	fixture.Response.Body = string(respBody)
+
+	if err := writeJSON(path, fixture); err != nil {
+		fmt.Printf("Warning: failed to record fixture %s: %v\n", path, err)
+	}
+	return resp, nil
+}
+
+// replay serves a previously recorded response
+func (r *recorder) replay(req *http.Request, path string) (*http.Response, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no recorded fixture for %s %s (%s): %v", req.Method, Redact(req.URL.String()), filepath.Base(path), err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %v", path, err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Response.StatusCode, http.StatusText(fixture.Response.StatusCode)),
+		StatusCode:    fixture.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        fixture.Response.Header,
+		Body:          io.NopCloser(strings.NewReader(fixture.Response.Body)),
+		ContentLength: int64(len(fixture.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *recorder) saveSession() error {
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return err
+	}
+	return writeJSON(filepath.Join(r.dir, "session.json"), session{RecordedAt: r.start})
+}
+
+func (r *recorder) loadSession() error {
+	data, err := os.ReadFile(filepath.Join(r.dir, "session.json"))
+	if err != nil {
+		return err
+	}
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	r.start = s.RecordedAt
+	return nil
+}
+
+// fixtureKey identifies a request by method, redacted URL and body.
+// Uploads must encode deterministically (see telegram's multipartBody)
+// for their fixtures to be found again.
+func fixtureKey(req *http.Request, url string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + url + "\n"))
+	h.Write([]byte(Redact(string(body))))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// isMultipart reports whether the request is a file upload, whose binary
+// body is left out of the saved fixture
+func isMultipart(req *http.Request) bool {
+	return strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/")
+}
+
+// savedHeaderNames are the only response headers kept in fixtures, so
+// cookies and other session headers are never committed
+var savedHeaderNames = []string{"Content-Type", "Retry-After"}
+
+// savedHeaders returns the allowlisted response headers
+func savedHeaders(header http.Header) http.Header {
+	saved := make(http.Header)
+	for _, name := range savedHeaderNames {
+		if values := header.Values(name); len(values) > 0 {
+			saved[name] = values
+		}
+	}
+	return saved
+}
+
+var (
+	botTokenPattern = regexp.MustCompile(`/bot[^/]+/`)
+	apiKeyPattern   = regexp.MustCompile(`([?&](?:key|crumb)=)[^&\s"]+`)
+
+	// Slack and Discord webhooks carry their token in the path
+	webhookPattern = regexp.MustCompile(`(hooks\.slack\.com/services|discord(?:app)?\.com/api/webhooks)/[^?#\s"]+`)
+)
+
+// Redact removes credentials from URLs and bodies before they are hashed or saved
+func Redact(s string) string {
+	s = botTokenPattern.ReplaceAllString(s, "/bot<redacted>/")
+	s = apiKeyPattern.ReplaceAllString(s, "${1}<redacted>")
+	s = webhookPattern.ReplaceAllString(s, "${1}/<redacted>")
+	return s
+}
+
+func writeJSON(path string, v interface{}) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false) // Keep fixtures readable
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
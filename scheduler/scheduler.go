@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Containers often ship without zoneinfo
+
+	"github.com/robfig/cron/v3"
+)
+
+// Job is a named task run on a cron schedule
+type Job struct {
+	Name     string
+	Schedule string // Standard 5-field cron expression
+	Run      func()
+}
+
+// Run registers the jobs in an in-process cron scheduler using the given
+// time zone and blocks until SIGINT or SIGTERM. A job that is still running
+// when its next tick arrives is skipped rather than started twice, and on
+// shutdown running jobs are allowed to finish.
+func Run(jobs []Job, timezone string) error {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("invalid scheduler timezone %q: %v", timezone, err)
+	}
+
+	logger := cron.PrintfLogger(log.New(os.Stdout, "cron: ", log.LstdFlags))
+	c := cron.New(
+		cron.WithLocation(location),
+		cron.WithLogger(logger),
+		cron.WithChain(cron.Recover(logger)),
+	)
+
+	for _, job := range jobs {
+		job := job
+		// Each job gets its own overlap guard so a slow job never blocks another
+		wrapped := cron.NewChain(cron.SkipIfStillRunning(logger)).Then(cron.FuncJob(func() {
+			fmt.Printf("Starting scheduled job %s\n", job.Name)
+			start := time.Now()
+			job.Run()
+			fmt.Printf("Finished scheduled job %s in %s\n", job.Name, time.Since(start).Round(time.Second))
+		}))
+
+		id, err := c.AddJob(job.Schedule, wrapped)
+		if err != nil {
+			return fmt.Errorf("invalid schedule %q for job %s: %v", job.Schedule, job.Name, err)
+		}
+		fmt.Printf("Scheduled %s at %q (%s), next run %s\n", job.Name, job.Schedule, location, c.Entry(id).Schedule.Next(time.Now().In(location)).Format(time.RFC1123))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	c.Start()
+	<-ctx.Done()
+
+	fmt.Println("Shutting down scheduler, waiting for running jobs to finish...")
+	<-c.Stop().Done()
+	fmt.Println("Scheduler stopped")
+	return nil
+}
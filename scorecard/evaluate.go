@@ -0,0 +1,75 @@
+package scorecard
+
+import (
+	"go-stock/indicators"
+	"go-stock/replay"
+	"go-stock/storage"
+)
+
+// ExpirySessions is how many sessions a call has to reach its first target
+// or stop-loss before it expires at the closing price
+const ExpirySessions = 20
+
+// Evaluate plays a BUY or SELL recommendation forward through the daily
+// bars after the day it was issued. The stop-loss is checked before the
+// target within a bar, so a bar touching both counts as a loss. A bar that
+// opens beyond either level exits at the open.
+func Evaluate(rec storage.Recommendation, bars indicators.Series) storage.Outcome {
+	r := rec.Recommendation
+	entry := r.Entry
+	if entry <= 0 {
+		entry = rec.Price
+	}
+	buy := r.Action == "BUY"
+
+	outcome := storage.Outcome{Status: storage.StatusOpen, Exit: entry, Evaluated: replay.Now()}
+	for _, bar := range bars {
+		date := storage.Date(bar.Time)
+		if date <= rec.Date {
+			continue
+		}
+		outcome.Sessions++
+		outcome.Exit = bar.Close
+
+		if exit, ok := reached(bar, r.StopLoss, !buy); ok {
+			outcome.Status, outcome.Date, outcome.Exit = storage.StatusStopHit, date, exit
+			break
+		}
+		if len(r.Targets) > 0 {
+			if exit, ok := reached(bar, r.Targets[0], buy); ok {
+				outcome.Status, outcome.Date, outcome.Exit = storage.StatusTargetHit, date, exit
+				break
+			}
+		}
+		if outcome.Sessions >= ExpirySessions {
+			outcome.Status, outcome.Date = storage.StatusExpired, date
+			break
+		}
+	}
+
+	if entry > 0 {
+		outcome.Return = (outcome.Exit - entry) / entry * 100
+		if !buy {
+			outcome.Return = -outcome.Return
+		}
+	}
+	return outcome
+}
+
+// reached reports whether a bar traded at or through level, above it if
+// above is set and below it otherwise, and the price it was reached at
+func reached(bar indicators.Bar, level float64, above bool) (float64, bool) {
+	if level <= 0 {
+		return 0, false
+	}
+	if above {
+		if bar.Open >= level {
+			return bar.Open, true
+		}
+		return level, bar.High >= level
+	}
+	if bar.Open > 0 && bar.Open <= level {
+		return bar.Open, true
+	}
+	return level, bar.Low <= level
+}
@@ -0,0 +1,152 @@
+package scorecard
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"go-stock/indicators"
+	"go-stock/recommend"
+	"go-stock/storage"
+)
+
+var ist = time.FixedZone("IST", 5*3600+1800)
+
+// bar returns a daily bar on June `day`, 2025
+func bar(day int, open, high, low, close float64) indicators.Bar {
+	return indicators.Bar{Time: time.Date(2025, 6, day, 9, 15, 0, 0, ist), Open: open, High: high, Low: low, Close: close}
+}
+
+// quiet returns bars from June `from` onwards that stay between 97 and 103
+func quiet(from, n int) indicators.Series {
+	var series indicators.Series
+	for i := 0; i < n; i++ {
+		series = append(series, bar(from+i, 100, 103, 97, 101))
+	}
+	return series
+}
+
+// call returns a recommendation issued on 02-Jun-2025 at a price of 100
+func call(action string, stopLoss float64, targets ...float64) storage.Recommendation {
+	return storage.Recommendation{
+		Symbol: "TCS.NS",
+		Date:   "2025-06-02",
+		Price:  100,
+		Recommendation: recommend.Recommendation{
+			Action:   action,
+			Entry:    100,
+			StopLoss: stopLoss,
+			Targets:  targets,
+		},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		rec      storage.Recommendation
+		bars     indicators.Series
+		status   string
+		date     string
+		exit     float64
+		ret      float64
+		sessions int
+	}{
+		{
+			name:   "nothing after issue",
+			rec:    call("BUY", 95, 110),
+			bars:   indicators.Series{bar(1, 100, 120, 80, 100), bar(2, 100, 120, 80, 100)},
+			status: storage.StatusOpen, exit: 100,
+		},
+		{
+			name:   "still open",
+			rec:    call("BUY", 95, 110),
+			bars:   quiet(3, 2),
+			status: storage.StatusOpen, exit: 101, ret: 1, sessions: 2,
+		},
+		{
+			name:   "BUY target",
+			rec:    call("BUY", 95, 110, 120),
+			bars:   append(quiet(3, 2), bar(5, 104, 111, 103, 109)),
+			status: storage.StatusTargetHit, date: "2025-06-05", exit: 110, ret: 10, sessions: 3,
+		},
+		{
+			name:   "BUY stop-loss",
+			rec:    call("BUY", 95, 110),
+			bars:   append(quiet(3, 1), bar(4, 98, 99, 94, 96)),
+			status: storage.StatusStopHit, date: "2025-06-04", exit: 95, ret: -5, sessions: 2,
+		},
+		{
+			// The order within the bar is unknown, so assume the worst
+			name:   "both in one bar",
+			rec:    call("BUY", 95, 110),
+			bars:   indicators.Series{bar(3, 100, 111, 94, 105)},
+			status: storage.StatusStopHit, date: "2025-06-03", exit: 95, ret: -5, sessions: 1,
+		},
+		{
+			name:   "gap over the target",
+			rec:    call("BUY", 95, 110),
+			bars:   indicators.Series{bar(3, 112, 115, 111, 114)},
+			status: storage.StatusTargetHit, date: "2025-06-03", exit: 112, ret: 12, sessions: 1,
+		},
+		{
+			name:   "gap under the stop-loss",
+			rec:    call("BUY", 95, 110),
+			bars:   indicators.Series{bar(3, 90, 92, 88, 91)},
+			status: storage.StatusStopHit, date: "2025-06-03", exit: 90, ret: -10, sessions: 1,
+		},
+		{
+			name:   "SELL target",
+			rec:    call("SELL", 105, 90),
+			bars:   append(quiet(3, 1), bar(4, 95, 96, 89, 91)),
+			status: storage.StatusTargetHit, date: "2025-06-04", exit: 90, ret: 10, sessions: 2,
+		},
+		{
+			name:   "SELL stop-loss",
+			rec:    call("SELL", 105, 90),
+			bars:   indicators.Series{bar(3, 101, 106, 100, 104)},
+			status: storage.StatusStopHit, date: "2025-06-03", exit: 105, ret: -5, sessions: 1,
+		},
+		{
+			name:   "SELL gap over the stop-loss",
+			rec:    call("SELL", 105, 90),
+			bars:   indicators.Series{bar(3, 108, 109, 107, 108)},
+			status: storage.StatusStopHit, date: "2025-06-03", exit: 108, ret: -8, sessions: 1,
+		},
+		{
+			name:   "expired",
+			rec:    call("BUY", 95, 110),
+			bars:   quiet(3, ExpirySessions+2),
+			status: storage.StatusExpired, date: "2025-06-22", exit: 101, ret: 1, sessions: ExpirySessions,
+		},
+		{
+			name:   "no targets",
+			rec:    call("BUY", 95),
+			bars:   indicators.Series{bar(3, 100, 130, 99, 125)},
+			status: storage.StatusOpen, exit: 125, ret: 25, sessions: 1,
+		},
+		{
+			name: "entry defaults to the price",
+			rec: func() storage.Recommendation {
+				rec := call("BUY", 95, 110)
+				rec.Recommendation.Entry = 0
+				return rec
+			}(),
+			bars:   indicators.Series{bar(3, 100, 111, 99, 110)},
+			status: storage.StatusTargetHit, date: "2025-06-03", exit: 110, ret: 10, sessions: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Evaluate(tt.rec, tt.bars)
+			if got.Status != tt.status || got.Date != tt.date || got.Sessions != tt.sessions {
+				t.Errorf("Evaluate() = %s on %q after %d sessions, want %s on %q after %d",
+					got.Status, got.Date, got.Sessions, tt.status, tt.date, tt.sessions)
+			}
+			if math.Abs(got.Exit-tt.exit) > 1e-9 || math.Abs(got.Return-tt.ret) > 1e-9 {
+				t.Errorf("Evaluate() exits at %.2f for %.2f%%, want %.2f for %.2f%%", got.Exit, got.Return, tt.exit, tt.ret)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+// Package scorecard tracks how the stored BUY and SELL recommendations
+// played out and reports their hit rate and returns each week.
+package scorecard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go-stock/config"
+	"go-stock/indicators"
+	"go-stock/notify"
+	"go-stock/recommend"
+	"go-stock/render"
+	"go-stock/replay"
+	"go-stock/stock"
+	"go-stock/storage"
+)
+
+// historyPadding is extra calendar days of history fetched beyond the
+// oldest open call, covering weekends and holidays
+const historyPadding = 10
+
+// RunScorecard evaluates every open recommendation against the daily bars
+// since it was issued, saves the outcomes and sends the weekly scorecard
+func RunScorecard(run *storage.Run) notify.Summary {
+	db, err := storage.Default()
+	if err != nil {
+		fmt.Printf("Error opening run history: %v\n", err)
+		return notify.Summary{}
+	}
+	recs, err := db.Recommendations()
+	if err == nil {
+		recs, err = updateOutcomes(db, recs, stock.NewDefaultProvider())
+	}
+	db.Close()
+	if err != nil {
+		fmt.Printf("Error evaluating recommendations: %v\n", err)
+		return notify.Summary{}
+	}
+
+	message := Report(recs, replay.Now())
+	fmt.Println(message.Text())
+
+	cfg := config.GetConfig()
+	var summary notify.Summary
+	summary.Add(notify.SendAll(notify.ForNames(cfg.ScorecardNotify, cfg.TelegramChatIDs), message)...)
+	return summary
+}
+
+// updateOutcomes evaluates the BUY and SELL calls that are not yet resolved,
+// saves them and returns every recommendation with its latest outcome.
+// Symbols whose history cannot be fetched keep their previous outcome.
+func updateOutcomes(db *storage.DB, recs []storage.Recommendation, provider stock.MarketDataProvider) ([]storage.Recommendation, error) {
+	// Fetch each symbol's history once, far enough back for its oldest open call
+	oldest := make(map[string]string)
+	for _, rec := range recs {
+		if tracked(rec) && !rec.Outcome.Resolved() {
+			if date, ok := oldest[rec.Symbol]; !ok || rec.Date < date {
+				oldest[rec.Symbol] = rec.Date
+			}
+		}
+	}
+
+	history := make(map[string]indicators.Series)
+	for symbol, date := range oldest {
+		issued, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			fmt.Printf("Warning: skipping %s with invalid date %q\n", symbol, date)
+			continue
+		}
+		days := int(replay.Now().Sub(issued).Hours()/24) + historyPadding
+		h, err := provider.DailyHistory(symbol, days)
+		if err != nil {
+			fmt.Printf("Warning: could not fetch history for %s, keeping its outcomes: %v\n", symbol, err)
+			continue
+		}
+		history[symbol] = h.Bars
+	}
+
+	var updated []storage.Recommendation
+	for i, rec := range recs {
+		bars, ok := history[rec.Symbol]
+		if !ok || !tracked(rec) || rec.Outcome.Resolved() {
+			continue
+		}
+		outcome := Evaluate(rec, bars)
+		recs[i].Outcome = &outcome
+		updated = append(updated, recs[i])
+	}
+	if err := db.SaveRecommendations(updated); err != nil {
+		return nil, err
+	}
+	fmt.Printf("Evaluated %d open recommendation(s)\n", len(updated))
+	return recs, nil
+}
+
+// tracked reports whether a recommendation is a call that can hit a target or stop
+func tracked(rec storage.Recommendation) bool {
+	return rec.Recommendation.Action == "BUY" || rec.Recommendation.Action == "SELL"
+}
+
+// stats summarises a set of resolved calls
+type stats struct {
+	Calls, Targets, Stops, Expired int
+	TotalReturn                    float64
+}
+
+func (s *stats) add(o *storage.Outcome) {
+	s.Calls++
+	s.TotalReturn += o.Return
+	switch o.Status {
+	case storage.StatusTargetHit:
+		s.Targets++
+	case storage.StatusStopHit:
+		s.Stops++
+	case storage.StatusExpired:
+		s.Expired++
+	}
+}
+
+// HitRate is the share of calls that reached their first target, in percent
+func (s stats) HitRate() float64 {
+	if s.Calls == 0 {
+		return 0
+	}
+	return float64(s.Targets) / float64(s.Calls) * 100
+}
+
+// AverageReturn is the mean return per call, in percent
+func (s stats) AverageReturn() float64 {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.TotalReturn / float64(s.Calls)
+}
+
+// Report builds the scorecard for the calls resolved in the week up to now:
+// overall hit rate and average return, the same split by risk level and by
+// model, the calls still open and the all-time totals
+func Report(recs []storage.Recommendation, now time.Time) notify.Message {
+	weekStart := storage.Date(now.AddDate(0, 0, -7))
+
+	var week, allTime stats
+	byRisk := make(map[string]*stats)
+	byModel := make(map[string]*stats)
+	open := 0
+	for _, rec := range recs {
+		if !tracked(rec) {
+			continue
+		}
+		if !rec.Outcome.Resolved() {
+			open++
+			continue
+		}
+		allTime.add(rec.Outcome)
+		if rec.Outcome.Date <= weekStart {
+			continue
+		}
+		week.add(rec.Outcome)
+		group(byRisk, rec.Recommendation.Risk).add(rec.Outcome)
+		group(byModel, rec.Source).add(rec.Outcome)
+	}
+
+	title := render.Document{render.Line{render.Plain("📋 "), render.Bold("Recommendation Scorecard"), render.Plain(" - week to " + now.Format("02-Jan-2006"))}}
+	if week.Calls == 0 {
+		return notify.Message{
+			Title: title,
+			Sections: []render.Document{
+				render.Text(fmt.Sprintf("No recommendations resolved this week. %d still open.", open)),
+				totals(allTime),
+			},
+		}
+	}
+
+	summary := render.Document{
+		render.Line{render.Bold("This week"), render.Plain(fmt.Sprintf(": %d resolved, %d still open", week.Calls, open))},
+		render.Line{render.Plain(fmt.Sprintf("🎯 Target hit: %d · 🛑 Stop hit: %d · ⌛ Expired: %d", week.Targets, week.Stops, week.Expired))},
+		render.Line{render.Plain(fmt.Sprintf("Hit rate: %.0f%% · Average return: %+.2f%%", week.HitRate(), week.AverageReturn()))},
+	}
+	return notify.Message{
+		Title: title,
+		Sections: []render.Document{
+			summary,
+			table("By risk level", "Risk", byRisk, recommend.RiskLevels),
+			table("By model", "Model", byModel, nil),
+			totals(allTime),
+		},
+	}
+}
+
+// group returns the stats for a key, creating them on first use
+func group(groups map[string]*stats, key string) *stats {
+	if key == "" {
+		key = "unknown"
+	}
+	if groups[key] == nil {
+		groups[key] = &stats{}
+	}
+	return groups[key]
+}
+
+// table shows calls, hit rate and average return for each group, in the
+// given order and then by name
+func table(heading, column string, groups map[string]*stats, order []string) render.Document {
+	names := make([]string, 0, len(groups))
+	width := len(column)
+	for name := range groups {
+		names = append(names, name)
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	rank := func(name string) int {
+		for i, o := range order {
+			if o == name {
+				return i
+			}
+		}
+		return len(order)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if ri, rj := rank(names[i]), rank(names[j]); ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-*s %5s %8s %10s", width, column, "Calls", "Hit rate", "Avg return")
+	for _, name := range names {
+		s := groups[name]
+		fmt.Fprintf(&sb, "\n%-*s %5d %7.0f%% %+9.2f%%", width, name, s.Calls, s.HitRate(), s.AverageReturn())
+	}
+	return render.Document{
+		render.Line{render.Bold(heading), render.Plain(":")},
+		render.Pre(sb.String()),
+	}
+}
+
+// totals is the all-time line
+func totals(s stats) render.Document {
+	return render.Document{render.Line{
+		render.Bold("All time"),
+		render.Plain(fmt.Sprintf(": %d resolved, hit rate %.0f%%, average return %+.2f%%", s.Calls, s.HitRate(), s.AverageReturn())),
+	}}
+}
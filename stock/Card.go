@@ -0,0 +1,308 @@
+package stock
+
+import (
+	"fmt"
+	"strings"
+
+	"go-stock/chart"
+	"go-stock/config"
+	"go-stock/indicators"
+	"go-stock/llm"
+	"go-stock/notify"
+	"go-stock/prompt"
+	"go-stock/recommend"
+	"go-stock/render"
+)
+
+// loadInput fetches the quote and daily history for a watchlist entry and computes its metrics
+func loadInput(provider MarketDataProvider, entry config.WatchlistSymbol, settings indicators.Settings) (stockInput, error) {
+	symbol := entry.Symbol
+	data, err := provider.Quote(symbol)
+	if err != nil {
+		return stockInput{}, fmt.Errorf("fetching %s: %v", symbol, err)
+	}
+
+	history, err := provider.DailyHistory(symbol, settings.LookbackDays())
+	if err != nil {
+		return stockInput{}, fmt.Errorf("fetching historical data for %s: %v", symbol, err)
+	}
+	for _, warning := range history.Warnings {
+		fmt.Printf("⚠️ Data quality warning for %s: %s\n", symbol, warning)
+	}
+	if len(history.Bars) == 0 {
+		return stockInput{}, fmt.Errorf("no usable historical bars for %s", symbol)
+	}
+
+	return stockInput{
+		Entry:    entry,
+		Quote:    data,
+		Metrics:  calculateMetrics(data, history.Bars, settings),
+		History:  history.Bars,
+		Settings: settings,
+	}, nil
+}
+
+// metricsCard is the price, volume and indicator part of a stock's report card
+func metricsCard(entry config.WatchlistSymbol, metrics StockMetrics) render.Document {
+	priceChangeEmoji := "📈"
+	if metrics.PriceChange < 0 {
+		priceChangeEmoji = "📉"
+	}
+
+	volumeChangeEmoji := "📊"
+	if metrics.VolumeChange > 20 {
+		volumeChangeEmoji = "🚀"
+	} else if metrics.VolumeChange < -20 {
+		volumeChangeEmoji = "📉"
+	}
+
+	technicalIndicators := fmt.Sprintf(
+		"Price vs %d-day MA: %.2f%%\n"+
+			"Price vs %d-day MA: %.2f%%\n"+
+			"RSI (%d): %.2f\n"+
+			"EMA (%d): %.2f\n"+
+			"MACD: %.2f / %.2f (hist %.2f)\n"+
+			"Bollinger: %.2f - %.2f (%%B %.2f)\n"+
+			"ATR (%d): %.2f\n"+
+			"Stoch %%K/%%D: %.2f / %.2f\n"+
+			"ADX (%d): %.2f\n"+
+			"Volatility: %.2f%%",
+		metrics.Settings.MAShortPeriod, metrics.PriceVsMAShort,
+		metrics.Settings.MALongPeriod, metrics.PriceVsMALong,
+		metrics.Settings.RSIPeriod, metrics.RSI,
+		metrics.Settings.EMAPeriod, metrics.EMA,
+		metrics.MACD, metrics.MACDSignal, metrics.MACDHistogram,
+		metrics.BollingerLower, metrics.BollingerUpper, metrics.PercentB,
+		metrics.Settings.ATRPeriod, metrics.ATR,
+		metrics.StochasticK, metrics.StochasticD,
+		metrics.Settings.ADXPeriod, metrics.ADX,
+		metrics.Volatility,
+	)
+
+	return render.Document{
+		render.Line{render.Bold(entry.DisplayName()), render.Plain(" (" + entry.Symbol + ")")},
+		render.Line{
+			render.Plain("💰 "), render.Bold("Price"),
+			render.Plain(fmt.Sprintf(": ₹%.2f %s (", metrics.Price, priceChangeEmoji)),
+			render.Bold(fmt.Sprintf("%.2f%%", metrics.PriceChange)), render.Plain(")"),
+		},
+		render.Line{
+			render.Plain("📈 "), render.Bold("Volume"),
+			render.Plain(fmt.Sprintf(": %s %.2f%% vs avg", volumeChangeEmoji, metrics.VolumeChange)),
+		},
+		render.Line{render.Plain("📊 "), render.Bold("Technical Indicators"), render.Plain(":")},
+		render.Pre(technicalIndicators),
+	}
+}
+
+// stockReport is one stock's section of a report
+type stockReport struct {
+	Card           render.Document
+	Chart          *notify.Image // nil when charts are off or could not be drawn
+	Recommendation recommend.Recommendation
+	Source         string // "provider/model" that wrote the recommendation, or rulesSource
+	PromptVersion  string // Version of the prompt templates used, empty for rules
+}
+
+// analyzer builds report cards, sharing market data and the model across a run
+type analyzer struct {
+	data    MarketDataProvider
+	model   llm.Provider // nil when no model is configured or insights are rule-based
+	batch   bool         // Ask for a whole group's recommendations in one request
+	prompts *prompt.Set
+}
+
+// newAnalyzer returns an analyzer using the default data provider, fetching
+// each symbol once, and the configured model unless insights are rule-based
+func newAnalyzer() *analyzer {
+	cfg := config.GetConfig()
+	a := &analyzer{data: newCachingProvider(NewDefaultProvider()), batch: cfg.LLM.Batch, prompts: prompt.Default()}
+	if cfg.InsightsMode == "rules" {
+		return a
+	}
+
+	model, err := llm.Default()
+	if err != nil {
+		fmt.Printf("Warning: AI insights unavailable, using the rule-based outlook: %v\n", err)
+		return a
+	}
+	a.model = model
+	return a
+}
+
+// stockInput is what the model is told about one watchlist entry
+type stockInput struct {
+	Entry    config.WatchlistSymbol
+	Quote    StockData
+	Metrics  StockMetrics
+	History  indicators.Series
+	Settings indicators.Settings
+}
+
+// insight is a stock's recommendation and what wrote it
+type insight struct {
+	Recommendation recommend.Recommendation
+	Source         string
+	PromptVersion  string
+}
+
+// recommendations returns an insight for each input, in order. In batch
+// mode the inputs are sent in one request and only the stocks the batch
+// could not answer are asked for one at a time. Stocks the model cannot
+// answer at all get the rule-based outlook, so none drop out of the report.
+func (a *analyzer) recommendations(inputs []stockInput) []insight {
+	insights := make([]insight, len(inputs))
+	if a.model == nil {
+		for i, input := range inputs {
+			insights[i] = ruleInsight(input)
+		}
+		return insights
+	}
+
+	source := a.model.Name() + "/" + a.model.Model()
+	var batch map[string]recommend.Recommendation
+	var batchPrompt prompt.Prompt
+	if a.batch && len(inputs) > 1 {
+		var err error
+		batchPrompt, err = buildBatchPrompt(a.prompts, inputs)
+		if err == nil {
+			batch, err = getBatchRecommendations(a.model, batchPrompt, inputs)
+		}
+		if err != nil {
+			fmt.Printf("Warning: batch insights failed, falling back to one request per stock: %v\n", err)
+		}
+	}
+
+	for i, input := range inputs {
+		if rec, ok := batch[input.Entry.Symbol]; ok {
+			insights[i] = insight{rec, source, batchPrompt.Version}
+			continue
+		}
+		p, err := buildPrompt(a.prompts, input)
+		if err == nil {
+			var rec recommend.Recommendation
+			if rec, err = getRecommendation(a.model, p, input); err == nil {
+				insights[i] = insight{rec, source, p.Version}
+				continue
+			}
+		}
+		fmt.Printf("Warning: AI insights failed for %s, using the rule-based outlook: %v\n", input.Entry.Symbol, err)
+		insights[i] = ruleInsight(input)
+	}
+	return insights
+}
+
+// ruleInsight is the rule-based outlook for a stock
+func ruleInsight(input stockInput) insight {
+	return insight{Recommendation: ruleRecommendation(input.Metrics, input.History), Source: rulesSource}
+}
+
+// report builds the full report card for a stock and its recommendation,
+// with a candlestick chart if withChart is set
+func (a *analyzer) report(input stockInput, in insight, withChart bool) stockReport {
+	heading := render.Line{render.Plain("🤖 "), render.Bold("AI Recommendation"), render.Plain(":")}
+	if in.Source == rulesSource {
+		heading = render.Line{render.Plain("📐 "), render.Bold("Rule-based Outlook"), render.Plain(":")}
+	}
+	card := append(metricsCard(input.Entry, input.Metrics), heading)
+	card = append(card, in.Recommendation.Render(input.Metrics.Price)...)
+	if in.PromptVersion != "" {
+		// Footer naming the model and prompt, so any report can be traced back
+		card = append(card, render.Line{render.Italic(in.Source + " · prompt " + in.PromptVersion)})
+	}
+	report := stockReport{
+		Card:           card,
+		Recommendation: in.Recommendation,
+		Source:         in.Source,
+		PromptVersion:  in.PromptVersion,
+	}
+	if withChart {
+		report.Chart = stockChart(input.Entry, input.History, input.Settings)
+	}
+	return report
+}
+
+// analyzeEntry builds the full report card for one watchlist entry,
+// including insights and, if withChart is set, a candlestick chart
+func (a *analyzer) analyzeEntry(entry config.WatchlistSymbol, settings indicators.Settings, withChart bool) (stockReport, error) {
+	input, err := loadInput(a.data, entry, settings)
+	if err != nil {
+		return stockReport{}, err
+	}
+	return a.report(input, a.recommendations([]stockInput{input})[0], withChart), nil
+}
+
+// stockChart draws the candlestick chart for an entry, or returns nil with a warning
+func stockChart(entry config.WatchlistSymbol, historicalData indicators.Series, settings indicators.Settings) *notify.Image {
+	caption := fmt.Sprintf("%s (%s)", entry.DisplayName(), entry.Symbol)
+	png, err := chart.Candlestick(caption, historicalData, settings)
+	if err != nil {
+		fmt.Printf("Warning: could not draw chart for %s: %v\n", entry.Symbol, err)
+		return nil
+	}
+	return &notify.Image{Name: entry.Symbol + ".png", PNG: png, Caption: caption}
+}
+
+// lookupEntry returns the watchlist entry and indicator settings for a
+// symbol, falling back to the global settings for symbols on no watchlist
+func lookupEntry(symbol string) (config.WatchlistSymbol, indicators.Settings) {
+	cfg := config.GetConfig()
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	for _, watchlist := range cfg.Watchlists {
+		for _, entry := range watchlist.Symbols {
+			if strings.EqualFold(entry.Symbol, symbol) {
+				return entry, watchlist.Indicators
+			}
+		}
+	}
+	return config.WatchlistSymbol{Symbol: symbol}, cfg.Indicators
+}
+
+// lookupSymbol returns the configured watchlist entry for a symbol, so chat
+// watchlists show the same display names
+func lookupSymbol(symbol string) config.WatchlistSymbol {
+	entry, _ := lookupEntry(symbol)
+	return entry
+}
+
+// CheckSymbol reports whether a quote can be fetched for symbol
+func CheckSymbol(symbol string) error {
+	_, err := NewDefaultProvider().Quote(symbol)
+	return err
+}
+
+// Quote returns the price and indicator card for a single symbol
+func Quote(symbol string) (render.Document, error) {
+	entry, settings := lookupEntry(symbol)
+	input, err := loadInput(NewDefaultProvider(), entry, settings)
+	if err != nil {
+		return nil, err
+	}
+	return metricsCard(entry, input.Metrics), nil
+}
+
+// RenderPrompt returns the prompt the model would be sent for a single
+// symbol, without calling the model
+func RenderPrompt(symbol string) (prompt.Prompt, error) {
+	entry, settings := lookupEntry(symbol)
+	input, err := loadInput(NewDefaultProvider(), entry, settings)
+	if err != nil {
+		return prompt.Prompt{}, err
+	}
+	return buildPrompt(prompt.Default(), input)
+}
+
+// Analyze returns the full report for a single symbol, including AI insights
+// and a chart
+func Analyze(symbol string) (notify.Message, error) {
+	entry, settings := lookupEntry(symbol)
+	report, err := newAnalyzer().analyzeEntry(entry, settings, true)
+	if err != nil {
+		return notify.Message{}, err
+	}
+	msg := notify.Message{Sections: []render.Document{report.Card}}
+	if report.Chart != nil {
+		msg.Images = append(msg.Images, *report.Chart)
+	}
+	return msg, nil
+}
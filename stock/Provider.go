@@ -0,0 +1,94 @@
+package stock
+
+import (
+	"fmt"
+	"sync"
+
+	"go-stock/config"
+	"go-stock/indicators"
+)
+
+// MarketDataProvider is a source of quotes and price history.
+// Implementations must be safe to reuse across symbols.
+type MarketDataProvider interface {
+	// Quote returns the latest quote for a symbol
+	Quote(symbol string) (StockData, error)
+
+	// DailyHistory returns daily bars, oldest-first, covering the last `days` calendar days
+	DailyHistory(symbol string, days int) (History, error)
+
+	// IntradayHistory returns bars of the given interval (e.g. "5m"), oldest-first, for the latest session
+	IntradayHistory(symbol string, interval string) (History, error)
+}
+
+// History is a bar series along with any data-quality issues found while loading it
+type History struct {
+	Bars     indicators.Series
+	Warnings []string
+}
+
+// NewDefaultProvider returns the provider used by the scheduled reports
+func NewDefaultProvider() MarketDataProvider {
+	cfg := config.GetConfig()
+	return NewYahooProvider(MissingBarPolicy(cfg.MissingBarPolicy))
+}
+
+// cachingProvider remembers every quote and history it fetches, so a symbol
+// that appears in several watchlists (or chats) is fetched once per run
+type cachingProvider struct {
+	provider MarketDataProvider
+
+	mu        sync.Mutex
+	quotes    map[string]StockData
+	histories map[string]History
+}
+
+// newCachingProvider wraps provider with a per-run cache
+func newCachingProvider(provider MarketDataProvider) *cachingProvider {
+	return &cachingProvider{
+		provider:  provider,
+		quotes:    make(map[string]StockData),
+		histories: make(map[string]History),
+	}
+}
+
+// Quote implements MarketDataProvider
+func (c *cachingProvider) Quote(symbol string) (StockData, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if data, ok := c.quotes[symbol]; ok {
+		return data, nil
+	}
+	data, err := c.provider.Quote(symbol)
+	if err == nil {
+		c.quotes[symbol] = data
+	}
+	return data, err
+}
+
+// DailyHistory implements MarketDataProvider
+func (c *cachingProvider) DailyHistory(symbol string, days int) (History, error) {
+	return c.history(fmt.Sprintf("%s|daily|%d", symbol, days), func() (History, error) {
+		return c.provider.DailyHistory(symbol, days)
+	})
+}
+
+// IntradayHistory implements MarketDataProvider
+func (c *cachingProvider) IntradayHistory(symbol string, interval string) (History, error) {
+	return c.history(fmt.Sprintf("%s|intraday|%s", symbol, interval), func() (History, error) {
+		return c.provider.IntradayHistory(symbol, interval)
+	})
+}
+
+func (c *cachingProvider) history(key string, fetch func() (History, error)) (History, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if history, ok := c.histories[key]; ok {
+		return history, nil
+	}
+	history, err := fetch()
+	if err == nil {
+		c.histories[key] = history
+	}
+	return history, err
+}
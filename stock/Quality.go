@@ -0,0 +1,101 @@
+package stock
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go-stock/indicators"
+)
+
+// MissingBarPolicy decides what happens to bars without a closing price
+type MissingBarPolicy string
+
+const (
+	// DropMissingBars removes bars with no closing price
+	DropMissingBars MissingBarPolicy = "drop"
+
+	// ForwardFillMissingBars replaces them with a flat, zero-volume bar at the previous close
+	ForwardFillMissingBars MissingBarPolicy = "ffill"
+)
+
+// rawBar is a bar as reported by a data source, where any field may be missing
+type rawBar struct {
+	Time   time.Time
+	Open   *float64
+	High   *float64
+	Low    *float64
+	Close  *float64
+	Volume *int64
+}
+
+// cleanBars converts raw bars into an oldest-first series using the given
+// policy, returning a warning for every kind of repair that was made
+func cleanBars(raw []rawBar, policy MissingBarPolicy) (indicators.Series, []string) {
+	var bars []indicators.Bar
+	var dropped, filled, partial, noVolume []string
+
+	// Forward-filling needs the previous bar, so order the input first
+	sorted := make([]rawBar, len(raw))
+	copy(sorted, raw)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	for _, r := range sorted {
+		date := r.Time.Format("02-Jan")
+
+		if r.Close == nil || *r.Close <= 0 {
+			if policy == ForwardFillMissingBars && len(bars) > 0 {
+				prev := bars[len(bars)-1].Close
+				bars = append(bars, indicators.Bar{Time: r.Time, Open: prev, High: prev, Low: prev, Close: prev})
+				filled = append(filled, date)
+			} else {
+				dropped = append(dropped, date)
+			}
+			continue
+		}
+
+		bar := indicators.Bar{
+			Time:  r.Time,
+			Open:  valueOr(r.Open, *r.Close),
+			High:  valueOr(r.High, *r.Close),
+			Low:   valueOr(r.Low, *r.Close),
+			Close: *r.Close,
+		}
+		if r.Open == nil || r.High == nil || r.Low == nil {
+			partial = append(partial, date)
+		}
+		if r.Volume != nil {
+			bar.Volume = *r.Volume
+		} else {
+			noVolume = append(noVolume, date)
+		}
+		bars = append(bars, bar)
+	}
+
+	var warnings []string
+	if len(dropped) > 0 {
+		warnings = append(warnings, fmt.Sprintf("dropped %d bar(s) with no close: %s", len(dropped), strings.Join(dropped, ", ")))
+	}
+	if len(filled) > 0 {
+		warnings = append(warnings, fmt.Sprintf("forward-filled %d bar(s) with no close: %s", len(filled), strings.Join(filled, ", ")))
+	}
+	if len(partial) > 0 {
+		warnings = append(warnings, fmt.Sprintf("filled missing open/high/low from close on %d bar(s): %s", len(partial), strings.Join(partial, ", ")))
+	}
+	if len(noVolume) > 0 {
+		warnings = append(warnings, fmt.Sprintf("treated missing volume as 0 on %d bar(s): %s", len(noVolume), strings.Join(noVolume, ", ")))
+	}
+
+	return indicators.NewSeries(bars), warnings
+}
+
+// valueOr dereferences v, returning fallback when it is nil
+func valueOr(v *float64, fallback float64) float64 {
+	if v == nil {
+		return fallback
+	}
+	return *v
+}
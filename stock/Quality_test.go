@@ -0,0 +1,126 @@
+package stock
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func price(v float64) *float64 { return &v }
+func volume(v int64) *int64    { return &v }
+
+// day returns a complete bar on June `d`, 2025 closing at `close`
+func day(d int, close float64) rawBar {
+	return rawBar{
+		Time:   time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC),
+		Open:   price(close - 1),
+		High:   price(close + 2),
+		Low:    price(close - 2),
+		Close:  price(close),
+		Volume: volume(1000),
+	}
+}
+
+// without returns bar with the named fields cleared
+func without(bar rawBar, fields ...string) rawBar {
+	for _, field := range fields {
+		switch field {
+		case "open":
+			bar.Open = nil
+		case "high":
+			bar.High = nil
+		case "low":
+			bar.Low = nil
+		case "close":
+			bar.Close = nil
+		case "volume":
+			bar.Volume = nil
+		}
+	}
+	return bar
+}
+
+func TestCleanBars(t *testing.T) {
+	zeroClose := day(3, 0)
+
+	tests := []struct {
+		name     string
+		raw      []rawBar
+		policy   MissingBarPolicy
+		want     string // Each bar as "date open/high/low/close volume"
+		warnings []string
+	}{
+		{
+			name:   "complete",
+			raw:    []rawBar{day(2, 100), day(3, 101)},
+			policy: DropMissingBars,
+			want:   "02 99/102/98/100 1000, 03 100/103/99/101 1000",
+		},
+		{
+			name:     "drop missing close",
+			raw:      []rawBar{day(2, 100), without(day(3, 101), "close"), day(4, 102)},
+			policy:   DropMissingBars,
+			want:     "02 99/102/98/100 1000, 04 101/104/100/102 1000",
+			warnings: []string{"dropped 1 bar(s) with no close: 03-Jun"},
+		},
+		{
+			name:     "drop zero close",
+			raw:      []rawBar{day(2, 100), zeroClose},
+			policy:   DropMissingBars,
+			want:     "02 99/102/98/100 1000",
+			warnings: []string{"dropped 1 bar(s) with no close: 03-Jun"},
+		},
+		{
+			name:     "ffill missing close",
+			raw:      []rawBar{day(2, 100), without(day(3, 101), "close"), without(day(4, 102), "close"), day(5, 103)},
+			policy:   ForwardFillMissingBars,
+			want:     "02 99/102/98/100 1000, 03 100/100/100/100 0, 04 100/100/100/100 0, 05 102/105/101/103 1000",
+			warnings: []string{"forward-filled 2 bar(s) with no close: 03-Jun, 04-Jun"},
+		},
+		{
+			// There is no previous close to carry forward
+			name:     "ffill first bar",
+			raw:      []rawBar{without(day(2, 100), "close"), day(3, 101)},
+			policy:   ForwardFillMissingBars,
+			want:     "03 100/103/99/101 1000",
+			warnings: []string{"dropped 1 bar(s) with no close: 02-Jun"},
+		},
+		{
+			// The missing bar sorts between the others, so it fills from 02-Jun
+			name:     "ffill unsorted",
+			raw:      []rawBar{day(4, 102), without(day(3, 101), "close"), day(2, 100)},
+			policy:   ForwardFillMissingBars,
+			want:     "02 99/102/98/100 1000, 03 100/100/100/100 0, 04 101/104/100/102 1000",
+			warnings: []string{"forward-filled 1 bar(s) with no close: 03-Jun"},
+		},
+		{
+			name:     "partial bar",
+			raw:      []rawBar{without(day(2, 100), "open", "low"), without(day(3, 101), "high", "volume")},
+			policy:   DropMissingBars,
+			want:     "02 100/102/100/100 1000, 03 100/101/99/101 0",
+			warnings: []string{"filled missing open/high/low from close on 2 bar(s): 02-Jun, 03-Jun", "treated missing volume as 0 on 1 bar(s): 03-Jun"},
+		},
+		{
+			name:   "empty",
+			policy: DropMissingBars,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series, warnings := cleanBars(tt.raw, tt.policy)
+
+			var bars []string
+			for _, bar := range series {
+				bars = append(bars, fmt.Sprintf("%s %g/%g/%g/%g %d", bar.Time.Format("02"), bar.Open, bar.High, bar.Low, bar.Close, bar.Volume))
+			}
+			if got := strings.Join(bars, ", "); got != tt.want {
+				t.Errorf("bars = %s\nwant   %s", got, tt.want)
+			}
+			if strings.Join(warnings, "\n") != strings.Join(tt.warnings, "\n") {
+				t.Errorf("warnings = %q, want %q", warnings, tt.warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package stock
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"go-stock/indicators"
+	"go-stock/recommend"
+)
+
+// ATR multiples for rule-based stop-losses and targets
+const (
+	stopATRs   = 1.5
+	target1ATR = 2.0
+	target2ATR = 3.0
+)
+
+// rulesSource labels recommendations written by the rules rather than a model
+const rulesSource = "rules"
+
+// signals are a stock's indicators classified in words
+type signals struct {
+	Trend  string // e.g. "5-day trend: 📈 +2.10%", empty with under 5 bars
+	RSI    string // Overbought, Oversold or Neutral
+	MA     string // Strong Uptrend, Uptrend, Sideways, Downtrend or Strong Downtrend
+	Volume string // Very High, High, Normal, Low or Very Low Volume
+}
+
+// classify describes a stock's trend, RSI, moving averages and volume
+func classify(metrics StockMetrics, historicalData indicators.Series) signals {
+	return signals{
+		Trend:  trendSignal(historicalData),
+		RSI:    rsiSignal(metrics.RSI),
+		MA:     maSignal(metrics),
+		Volume: volumeSignal(metrics),
+	}
+}
+
+// trendSignal describes the close-to-close change over the last 5 bars
+func trendSignal(historicalData indicators.Series) string {
+	if len(historicalData) < 5 {
+		return ""
+	}
+	recent := historicalData.Tail(5)
+	firstPrice := recent[0].Close // 5th day back
+	lastPrice := recent[4].Close  // Most recent
+	if firstPrice <= 0 {
+		return ""
+	}
+	trendChange := ((lastPrice - firstPrice) / firstPrice) * 100
+	if trendChange > 0 {
+		return fmt.Sprintf("5-day trend: 📈 +%.2f%%", trendChange)
+	}
+	return fmt.Sprintf("5-day trend: 📉 %.2f%%", trendChange)
+}
+
+func rsiSignal(rsi float64) string {
+	if rsi > 70 {
+		return "Overbought"
+	} else if rsi < 30 {
+		return "Oversold"
+	}
+	return "Neutral"
+}
+
+func maSignal(metrics StockMetrics) string {
+	if metrics.PriceVsMAShort > 1 && metrics.PriceVsMALong > 1 {
+		return "Strong Uptrend"
+	} else if metrics.PriceVsMAShort > 0 && metrics.PriceVsMALong > 0 {
+		return "Uptrend"
+	} else if metrics.PriceVsMAShort < -1 && metrics.PriceVsMALong < -1 {
+		return "Strong Downtrend"
+	} else if metrics.PriceVsMAShort < 0 && metrics.PriceVsMALong < 0 {
+		return "Downtrend"
+	}
+	return "Sideways"
+}
+
+func volumeSignal(metrics StockMetrics) string {
+	if metrics.VolumeChange > 50 {
+		return "Very High Volume"
+	} else if metrics.VolumeChange > 20 {
+		return "High Volume"
+	} else if metrics.VolumeChange < -50 {
+		return "Very Low Volume"
+	} else if metrics.VolumeChange < -20 {
+		return "Low Volume"
+	}
+	return "Normal Volume"
+}
+
+// maScores weighs each moving-average signal
+var maScores = map[string]int{
+	"Strong Uptrend":   2,
+	"Uptrend":          1,
+	"Sideways":         0,
+	"Downtrend":        -1,
+	"Strong Downtrend": -2,
+}
+
+// maPhrases describe each moving-average signal in a sentence
+var maPhrases = map[string]string{
+	"Strong Uptrend":   "in a strong uptrend",
+	"Uptrend":          "in an uptrend",
+	"Sideways":         "moving sideways",
+	"Downtrend":        "in a downtrend",
+	"Strong Downtrend": "in a strong downtrend",
+}
+
+// ruleRecommendation builds a recommendation from the indicators alone.
+// Trend, RSI and MACD each vote; a clear majority gives BUY or SELL with
+// stop-loss and targets at multiples of the ATR, anything else is HOLD.
+// A call that fails the same validation as a model's, such as a SELL on a
+// volatile penny stock with targets at or below zero, becomes HOLD.
+func ruleRecommendation(metrics StockMetrics, historicalData indicators.Series) recommend.Recommendation {
+	s := classify(metrics, historicalData)
+	price := metrics.Price
+
+	score := maScores[s.MA]
+	switch s.RSI {
+	case "Oversold":
+		score++
+	case "Overbought":
+		score--
+	}
+	if metrics.MACDHistogram > 0 {
+		score++
+	} else if metrics.MACDHistogram < 0 {
+		score--
+	}
+	// Heavy volume confirms a move that is already clear
+	if (score >= 2 || score <= -2) && strings.HasSuffix(s.Volume, "High Volume") {
+		score += score / abs(score)
+	}
+
+	atr := metrics.ATR
+	if atr <= 0 {
+		atr = metrics.DailyRange
+	}
+	if atr <= 0 {
+		atr = price * 0.02
+	}
+
+	rec := recommend.Recommendation{Action: "HOLD", Risk: riskLevel(atr, price)}
+	rec.Support, rec.Resistance = supportResistance(metrics, historicalData)
+	switch {
+	case score >= 3 && s.RSI != "Overbought":
+		rec.Action = "BUY"
+		rec.Entry = round2(price)
+		rec.StopLoss = round2(price - stopATRs*atr)
+		rec.Targets = []float64{round2(price + target1ATR*atr), round2(price + target2ATR*atr)}
+	case score <= -3 && s.RSI != "Oversold":
+		rec.Action = "SELL"
+		rec.Entry = round2(price)
+		rec.StopLoss = round2(price + stopATRs*atr)
+		rec.Targets = positiveLevels(round2(price-target1ATR*atr), round2(price-target2ATR*atr))
+	}
+	rec.Rationale = narrate(metrics, s, rec)
+
+	if err := rec.Validate(price); err != nil && rec.Action != "HOLD" {
+		fmt.Printf("Warning: rule-based %s for %s failed validation, holding instead: %v\n", rec.Action, metrics.Symbol, err)
+		rec = recommend.Recommendation{Action: "HOLD", Risk: rec.Risk, Support: rec.Support, Resistance: rec.Resistance}
+		rec.Rationale = narrate(metrics, s, rec)
+	}
+	return rec
+}
+
+// positiveLevels drops levels at or below zero
+func positiveLevels(levels ...float64) []float64 {
+	var positive []float64
+	for _, level := range levels {
+		if level > 0 {
+			positive = append(positive, level)
+		}
+	}
+	return positive
+}
+
+// riskLevel grades the daily ATR as a share of the price
+func riskLevel(atr, price float64) string {
+	if price <= 0 {
+		return "High"
+	}
+	switch atrPercent := atr / price * 100; {
+	case atrPercent < 2:
+		return "Low"
+	case atrPercent < 4:
+		return "Medium"
+	default:
+		return "High"
+	}
+}
+
+// supportResistance returns the swing low and lower Bollinger Band below the
+// price, nearest first, and the swing high and upper band above it
+func supportResistance(metrics StockMetrics, historicalData indicators.Series) ([]float64, []float64) {
+	price := metrics.Price
+	var support, resistance []float64
+	add := func(level float64) {
+		level = round2(level)
+		if level > 0 && level < price && !containsLevel(support, level) {
+			support = append(support, level)
+		} else if level > price && !containsLevel(resistance, level) {
+			resistance = append(resistance, level)
+		}
+	}
+
+	window := historicalData.Tail(metrics.Settings.MALongPeriod)
+	if len(window) > 0 {
+		low, high := window[0].Low, window[0].High
+		for _, bar := range window[1:] {
+			if bar.Low > 0 && bar.Low < low {
+				low = bar.Low
+			}
+			if bar.High > high {
+				high = bar.High
+			}
+		}
+		add(low)
+		add(high)
+	}
+	add(metrics.BollingerLower)
+	add(metrics.BollingerUpper)
+
+	sort.Sort(sort.Reverse(sort.Float64Slice(support)))
+	sort.Float64s(resistance)
+	return support, resistance
+}
+
+// narrativeTemplate turns the signals and levels into the rationale
+var narrativeTemplate = template.Must(template.New("narrative").Funcs(template.FuncMap{
+	"rupees": func(v float64) string { return fmt.Sprintf("₹%.2f", v) },
+	"pct":    func(v float64) string { return fmt.Sprintf("%+.2f%%", v) },
+	"targets": func(levels []float64) string {
+		if len(levels) == 1 {
+			return fmt.Sprintf("a target of ₹%.2f", levels[0])
+		}
+		return fmt.Sprintf("targets of ₹%.2f and ₹%.2f", levels[0], levels[len(levels)-1])
+	},
+}).Parse(
+	`{{.Symbol}} is {{.Trend}}, trading {{pct .PriceVsMALong}} against its {{.MALongPeriod}}-day average. ` +
+		`RSI at {{printf "%.1f" .RSI}} is {{.RSISignal}}, MACD momentum is {{.Momentum}} and volume is {{.Volume}}. ` +
+		`{{with .Rec}}{{if eq .Action "BUY"}}The indicators favour buyers: enter near {{rupees .Entry}} with a stop-loss at {{rupees .StopLoss}}, {{$.StopATRs}}× ATR below, and {{targets .Targets}}.` +
+		`{{else if eq .Action "SELL"}}The indicators favour sellers: exit or short near {{rupees .Entry}} with a stop-loss at {{rupees .StopLoss}}, {{$.StopATRs}}× ATR above, and {{targets .Targets}}.` +
+		`{{else}}The signals are mixed, so wait for a clearer setup{{if .Support}}; support sits at {{rupees (index .Support 0)}}{{end}}{{if .Resistance}}{{if .Support}} and{{else}};{{end}} resistance at {{rupees (index .Resistance 0)}}{{end}}.{{end}}{{end}}`,
+))
+
+// narrate writes the rationale for a rule-based recommendation
+func narrate(metrics StockMetrics, s signals, rec recommend.Recommendation) string {
+	momentum := "flat"
+	if metrics.MACDHistogram > 0 {
+		momentum = "positive"
+	} else if metrics.MACDHistogram < 0 {
+		momentum = "negative"
+	}
+
+	var sb strings.Builder
+	err := narrativeTemplate.Execute(&sb, map[string]interface{}{
+		"Symbol":        metrics.Symbol,
+		"Trend":         maPhrases[s.MA],
+		"PriceVsMALong": metrics.PriceVsMALong,
+		"MALongPeriod":  metrics.Settings.MALongPeriod,
+		"RSI":           metrics.RSI,
+		"RSISignal":     strings.ToLower(s.RSI),
+		"Momentum":      momentum,
+		"Volume":        strings.ToLower(strings.TrimSuffix(s.Volume, " Volume")),
+		"StopATRs":      stopATRs,
+		"Rec":           rec,
+	})
+	if err != nil {
+		return fmt.Sprintf("Rule-based outlook: %s, RSI %s, %s.", s.MA, s.RSI, s.Volume)
+	}
+	return sb.String()
+}
+
+func containsLevel(levels []float64, level float64) bool {
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+func round2(x float64) float64 {
+	return float64(int64(x*100+0.5)) / 100
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
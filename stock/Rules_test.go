@@ -0,0 +1,61 @@
+package stock
+
+import (
+	"strings"
+	"testing"
+
+	"go-stock/indicators"
+)
+
+func TestRuleRecommendation(t *testing.T) {
+	// trend returns metrics whose moving averages and MACD all vote the same way
+	trend := func(direction float64, price, atr float64) StockMetrics {
+		return StockMetrics{
+			Symbol:         "PENNY.NS",
+			Price:          price,
+			PriceVsMAShort: 2 * direction,
+			PriceVsMALong:  2 * direction,
+			RSI:            50,
+			MACDHistogram:  direction,
+			ATR:            atr,
+			Settings:       indicators.DefaultSettings(),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		metrics  StockMetrics
+		action   string
+		stopLoss float64
+		targets  []float64
+	}{
+		{"BUY", trend(1, 100, 2), "BUY", 97, []float64{104, 106}},
+		{"SELL", trend(-1, 100, 2), "SELL", 103, []float64{96, 94}},
+		{"mixed", StockMetrics{Price: 100, RSI: 50, ATR: 2}, "HOLD", 0, nil},
+		// 10 - 3×4 is below zero, so only the first target is kept
+		{"SELL drops a negative target", trend(-1, 10, 4), "SELL", 16, []float64{2}},
+		{"SELL with no positive target", trend(-1, 10, 6), "HOLD", 0, nil},
+		{"BUY with a negative stop-loss", trend(1, 10, 7), "HOLD", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := ruleRecommendation(tt.metrics, nil)
+			if rec.Action != tt.action || rec.StopLoss != tt.stopLoss || len(rec.Targets) != len(tt.targets) {
+				t.Fatalf("ruleRecommendation() = %s stop %v targets %v, want %s stop %v targets %v",
+					rec.Action, rec.StopLoss, rec.Targets, tt.action, tt.stopLoss, tt.targets)
+			}
+			for i, target := range tt.targets {
+				if rec.Targets[i] != target {
+					t.Errorf("target %d = %v, want %v", i+1, rec.Targets[i], target)
+				}
+			}
+			if strings.HasPrefix(rec.Rationale, "Rule-based outlook:") {
+				t.Errorf("rationale fell back to the summary: %s", rec.Rationale)
+			}
+			if err := rec.Validate(tt.metrics.Price); err != nil {
+				t.Errorf("Validate() = %v", err)
+			}
+		})
+	}
+}
@@ -1,42 +1,26 @@
 package stock
 
 import (
-	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"strings"
-	"time"
-
-	"github.com/go-resty/resty/v2"
 
+	"go-stock/calendar"
+	"go-stock/chatlist"
 	"go-stock/config"
+	"go-stock/indicators"
+	"go-stock/llm"
+	"go-stock/marketcap"
+	"go-stock/notify"
+	"go-stock/prompt"
+	"go-stock/recommend"
+	"go-stock/render"
+	"go-stock/replay"
+	"go-stock/storage"
 )
 
-// Configurable list of Indian stocks (expandable via config)
-var defaultStocks = []string{
-	// Large Cap Stocks (Market Cap > ₹50,000 Cr)
-	"RELIANCE.NS",  // Reliance Industries - Energy & Retail
-	"TCS.NS",       // Tata Consultancy Services - IT
-	"HDFCBANK.NS",  // HDFC Bank - Banking
-	"INFY.NS",      // Infosys - IT
-	"ICICIBANK.NS", // ICICI Bank - Banking
-
-	// Mid Cap Stocks (Market Cap ₹10,000-50,000 Cr)
-	"TATAMOTORS.NS", // Tata Motors - Auto
-	"ADANIENT.NS",   // Adani Enterprises - Infrastructure
-	"BAJFINANCE.NS", // Bajaj Finance - NBFC
-	"TITAN.NS",      // Titan Company - Consumer Goods
-	"MARICO.NS",     // Marico - FMCG
-
-	// Small Cap Stocks (Market Cap < ₹10,000 Cr)
-	"JUBLFOOD.NS",   // Jubilant FoodWorks - Food Services
-	"FORTIS.NS",     // Fortis Healthcare - Healthcare
-	"KALYANKJIL.NS", // Kalyan Jewellers - Retail
-	"SUPREMEIND.NS", // Supreme Industries - Plastics
-	"VBL.NS",        // Varun Beverages - Beverages
-}
-
 type StockData struct {
 	Symbol        string  `json:"symbol"`
 	Price         float64 `json:"regularMarketPrice"`
@@ -47,526 +31,452 @@ type StockData struct {
 }
 
 type StockMetrics struct {
-	Symbol       string
-	Price        float64
-	PriceChange  float64
-	DailyRange   float64
-	Volatility   float64
-	Volume       int64
-	VolumeChange float64
-	MA5          float64 // 5-day moving average
-	MA20         float64 // 20-day moving average
-	PriceVsMA5   float64 // Price vs 5-day MA
-	PriceVsMA20  float64 // Price vs 20-day MA
-	RSI          float64 // 14-day RSI
-}
-
-type GeminiResponse struct {
-	Candidates []struct {
-		Content struct {
-			Parts []struct {
-				Text string `json:"text"`
-			} `json:"parts"`
-		} `json:"content"`
-	} `json:"candidates"`
+	Symbol         string
+	Price          float64
+	PriceChange    float64
+	DailyRange     float64
+	Volatility     float64
+	Volume         int64
+	VolumeChange   float64
+	MAShort        float64 // Short moving average (default 5-day)
+	MALong         float64 // Long moving average (default 20-day)
+	PriceVsMAShort float64 // Price vs short MA
+	PriceVsMALong  float64 // Price vs long MA
+	RSI            float64 // Wilder RSI (default 14-day)
+	EMA            float64 // Exponential moving average (default 20-day)
+	MACD           float64 // MACD line
+	MACDSignal     float64 // MACD signal line
+	MACDHistogram  float64 // MACD line minus signal line
+	BollingerUpper float64 // Upper Bollinger Band
+	BollingerMid   float64 // Middle Bollinger Band
+	BollingerLower float64 // Lower Bollinger Band
+	PercentB       float64 // Position within the Bollinger Bands (0 = lower, 1 = upper)
+	ATR            float64 // Average True Range
+	StochasticK    float64 // Stochastic %K
+	StochasticD    float64 // Stochastic %D
+	ADX            float64 // Average Directional Index
+	PlusDI         float64 // +DI
+	MinusDI        float64 // -DI
+	Settings       indicators.Settings
 }
 
-// Send Telegram notification with improved formatting
-func sendStockTelegramNotification(message string) {
-	cfg := config.GetConfig()
-	if cfg.TelegramBotToken == "" || len(cfg.TelegramChatIDs) == 0 {
-		fmt.Println("Warning: Telegram credentials not set")
-		return
-	}
-
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", cfg.TelegramBotToken)
-
-	// Send to each chat ID
-	for _, chatID := range cfg.TelegramChatIDs {
-		payload := map[string]string{
-			"chat_id":    chatID,
-			"text":       message,
-			"parse_mode": "Markdown", // Enable Markdown formatting
-		}
-
-		payloadBytes, _ := json.Marshal(payload)
-		resp, err := http.Post(url, "application/json", bytes.NewBuffer(payloadBytes))
-		if err != nil {
-			fmt.Printf("Error sending Telegram notification to chat %s: %v\n", chatID, err)
-			continue
-		}
-		defer resp.Body.Close()
+// Calculate stock metrics from the current quote and oldest-first daily history
+func calculateMetrics(data StockData, historicalData indicators.Series, settings indicators.Settings) StockMetrics {
+	priceChange := indicators.PercentChange(data.PreviousClose, data.Price)
+	dailyRange := data.High - data.Low
+	volatility := (dailyRange / data.Price) * 100
+	avgVolume := historicalData.Tail(settings.MALongPeriod).AverageVolume()
+	volumeChange := indicators.PercentChange(float64(avgVolume), float64(data.Volume))
+
+	closes := historicalData.Closes()
+	maShort := indicators.SMA(closes, settings.MAShortPeriod)
+	maLong := indicators.SMA(closes, settings.MALongPeriod)
+	priceVsMAShort := indicators.PercentChange(maShort, data.Price)
+	priceVsMALong := indicators.PercentChange(maLong, data.Price)
+	rsi := indicators.RSI(closes, settings.RSIPeriod)
+	ema := indicators.EMA(closes, settings.EMAPeriod)
+	macd, macdSignal, macdHistogram := indicators.MACD(closes, settings.MACDFastPeriod, settings.MACDSlowPeriod, settings.MACDSignalPeriod)
+	bbUpper, bbMid, bbLower, percentB := indicators.BollingerBands(closes, settings.BollingerPeriod, settings.BollingerStdDev)
+	atr := indicators.ATR(historicalData, settings.ATRPeriod)
+	stochK, stochD := indicators.Stochastic(historicalData, settings.StochasticK, settings.StochasticD)
+	adx, plusDI, minusDI := indicators.ADX(historicalData, settings.ADXPeriod)
 
-		if resp.StatusCode == http.StatusOK {
-			fmt.Printf("Notification sent successfully to chat %s!\n", chatID)
-		} else {
-			fmt.Printf("Failed to send notification to chat %s. Status code: %d\n", chatID, resp.StatusCode)
-		}
+	return StockMetrics{
+		Symbol:         data.Symbol,
+		Price:          data.Price,
+		PriceChange:    priceChange,
+		DailyRange:     dailyRange,
+		Volatility:     volatility,
+		Volume:         data.Volume,
+		VolumeChange:   volumeChange,
+		MAShort:        maShort,
+		MALong:         maLong,
+		PriceVsMAShort: priceVsMAShort,
+		PriceVsMALong:  priceVsMALong,
+		RSI:            rsi,
+		EMA:            ema,
+		MACD:           macd,
+		MACDSignal:     macdSignal,
+		MACDHistogram:  macdHistogram,
+		BollingerUpper: bbUpper,
+		BollingerMid:   bbMid,
+		BollingerLower: bbLower,
+		PercentB:       percentB,
+		ATR:            atr,
+		StochasticK:    stochK,
+		StochasticD:    stochD,
+		ADX:            adx,
+		PlusDI:         plusDI,
+		MinusDI:        minusDI,
+		Settings:       settings,
 	}
 }
 
-// Fetch current stock data from Yahoo Finance
-func fetchYahooFinanceData(symbol string) (StockData, error) {
-	client := resty.New().
-		SetRetryCount(3).                     // Retry on failure
-		SetRetryWaitTime(2 * time.Second).    // Initial wait
-		SetRetryMaxWaitTime(10 * time.Second) // Max wait
-
-	url := fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s", symbol)
-	resp, err := client.R().
-		SetHeader("User-Agent", "Mozilla/5.0").
-		Get(url)
-
+// Get a structured recommendation for a stock from the configured model,
+// validated against the current price
+func getRecommendation(model llm.Provider, p prompt.Prompt, input stockInput) (recommend.Recommendation, error) {
+	if model == nil {
+		return recommend.Recommendation{}, fmt.Errorf("no LLM provider configured")
+	}
+
+	price := input.Metrics.Price
+	reply, err := model.Generate(llm.Request{
+		Prompt:   p.Text,
+		Schema:   recommend.Schema,
+		CacheKey: cacheKey(p, recommend.Instructions, recommend.Schema, input),
+		Accept: func(reply string) error {
+			_, err := parseRecommendation(reply, price)
+			return err
+		},
+	})
 	if err != nil {
-		return StockData{}, fmt.Errorf("failed to fetch data for %s: %v", symbol, err)
-	}
-
-	var result struct {
-		Chart struct {
-			Result []struct {
-				Meta struct {
-					RegularMarketPrice   float64 `json:"regularMarketPrice"`
-					PreviousClose        float64 `json:"previousClose"`
-					RegularMarketDayHigh float64 `json:"regularMarketDayHigh"`
-					RegularMarketDayLow  float64 `json:"regularMarketDayLow"`
-					RegularMarketVolume  int64   `json:"regularMarketVolume"`
-				} `json:"meta"`
-			} `json:"result"`
-		} `json:"chart"`
-	}
-
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
-		return StockData{}, fmt.Errorf("failed to parse response for %s: %v", symbol, err)
-	}
-
-	if len(result.Chart.Result) == 0 {
-		return StockData{}, fmt.Errorf("no data found for symbol %s", symbol)
-	}
-
-	meta := result.Chart.Result[0].Meta
-
-	// Validate critical fields
-	if meta.RegularMarketPrice <= 0 || meta.PreviousClose <= 0 {
-		return StockData{}, fmt.Errorf("invalid price data for %s: price=%.2f, previousClose=%.2f", symbol, meta.RegularMarketPrice, meta.PreviousClose)
+		return recommend.Recommendation{}, err
 	}
-
-	return StockData{
-		Symbol:        symbol,
-		Price:         meta.RegularMarketPrice,
-		PreviousClose: meta.PreviousClose,
-		High:          meta.RegularMarketDayHigh,
-		Low:           meta.RegularMarketDayLow,
-		Volume:        meta.RegularMarketVolume,
-	}, nil
+	return parseRecommendation(reply, price)
 }
 
-// Fetch historical data (30 days for better MA and RSI)
-func fetchHistoricalData(symbol string) ([]StockData, error) {
-	client := resty.New().
-		SetRetryCount(3).
-		SetRetryWaitTime(2 * time.Second).
-		SetRetryMaxWaitTime(10 * time.Second)
-
-	endTime := time.Now()
-	startTime := endTime.AddDate(0, 0, -30) // Fetch 30 days to cover holidays
-
-	url := fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s", symbol)
-	resp, err := client.R().
-		SetHeader("User-Agent", "Mozilla/5.0").
-		SetQueryParams(map[string]string{
-			"period1":  fmt.Sprintf("%d", startTime.Unix()),
-			"period2":  fmt.Sprintf("%d", endTime.Unix()),
-			"interval": "1d",
-		}).
-		Get(url)
-
+// parseRecommendation parses a reply and validates it against the price
+func parseRecommendation(reply string, price float64) (recommend.Recommendation, error) {
+	rec, err := recommend.Parse(reply)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch historical data for %s: %v", symbol, err)
+		return recommend.Recommendation{}, err
 	}
-
-	var result struct {
-		Chart struct {
-			Result []struct {
-				Timestamp  []int64 `json:"timestamp"`
-				Indicators struct {
-					Quote []struct {
-						Close  []float64 `json:"close"`
-						High   []float64 `json:"high"`
-						Low    []float64 `json:"low"`
-						Volume []int64   `json:"volume"`
-					} `json:"quote"`
-				} `json:"indicators"`
-			} `json:"result"`
-		} `json:"chart"`
+	if err := rec.Validate(price); err != nil {
+		return recommend.Recommendation{}, fmt.Errorf("rejected recommendation: %v", err)
 	}
+	return rec, nil
+}
 
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
-		return nil, fmt.Errorf("failed to parse historical response for %s: %v", symbol, err)
+// getBatchRecommendations asks for every stock's recommendation in one
+// request and returns the valid ones by symbol. Stocks missing from the
+// response or failing validation are left out for the caller to retry.
+func getBatchRecommendations(model llm.Provider, p prompt.Prompt, inputs []stockInput) (map[string]recommend.Recommendation, error) {
+	reply, err := model.Generate(llm.Request{
+		Prompt:   p.Text,
+		Schema:   recommend.BatchSchema,
+		CacheKey: cacheKey(p, recommend.BatchInstructions, recommend.BatchSchema, inputs...),
+		Accept: func(reply string) error {
+			_, _, err := parseBatch(reply, inputs)
+			return err
+		},
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	if len(result.Chart.Result) == 0 || len(result.Chart.Result[0].Indicators.Quote) == 0 {
-		return nil, fmt.Errorf("no historical data found for symbol %s", symbol)
+	recs, problems, err := parseBatch(reply, inputs)
+	if err != nil {
+		return nil, err
 	}
-
-	quote := result.Chart.Result[0].Indicators.Quote[0]
-	var historicalData []StockData
-
-	for i := 0; i < len(quote.Close); i++ {
-		historicalData = append(historicalData, StockData{
-			Symbol:        symbol,
-			Price:         quote.Close[i],
-			High:          quote.High[i],
-			Low:           quote.Low[i],
-			Volume:        quote.Volume[i],
-			PreviousClose: quote.Close[i], // For simplicity, reuse close as previous close
-		})
+	for _, problem := range problems {
+		fmt.Printf("Warning: %s\n", problem)
 	}
-
-	return historicalData, nil
+	return recs, nil
 }
 
-// Calculate moving averages with sufficient data
-func calculateMovingAverages(historicalData []StockData) (float64, float64) {
-	if len(historicalData) == 0 {
-		return 0, 0
+// parseBatch parses a batch reply and returns the valid recommendations by
+// symbol, with a note for each stock left out. A reply with no valid
+// recommendation at all is an error.
+func parseBatch(reply string, inputs []stockInput) (map[string]recommend.Recommendation, []string, error) {
+	parsed, err := recommend.ParseBatch(reply)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// 5-day MA
-	ma5Period := min(5, len(historicalData))
-	var ma5 float64
-	for i := 0; i < ma5Period; i++ {
-		ma5 += historicalData[i].Price
+	recs := make(map[string]recommend.Recommendation, len(inputs))
+	var problems []string
+	for _, input := range inputs {
+		symbol := input.Entry.Symbol
+		rec, ok := parsed[strings.ToUpper(symbol)]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("batch response has no recommendation for %s", symbol))
+			continue
+		}
+		if err := rec.Validate(input.Metrics.Price); err != nil {
+			problems = append(problems, fmt.Sprintf("rejected batch recommendation for %s: %v", symbol, err))
+			continue
+		}
+		recs[symbol] = rec
 	}
-	ma5 /= float64(ma5Period)
-
-	// 20-day MA
-	ma20Period := min(20, len(historicalData))
-	var ma20 float64
-	for i := 0; i < ma20Period; i++ {
-		ma20 += historicalData[i].Price
+	if len(recs) == 0 {
+		return nil, problems, fmt.Errorf("no valid recommendations in batch response")
 	}
-	ma20 /= float64(ma20Period)
-
-	return ma5, ma20
+	return recs, problems, nil
 }
 
-// Fixed RSI calculation (14-day period, correct sequence)
-func calculateRSI(prices []float64) float64 {
-	if len(prices) < 15 { // Need 14 days + 1 for change
-		return 50 // Neutral if insufficient data
-	}
-
-	var gains, losses float64
-	// Use the most recent 14 days (newest first in historical data)
-	for i := 1; i <= 14; i++ {
-		change := prices[i-1] - prices[i] // Newer - Older
-		if change > 0 {
-			gains += change
-		} else {
-			losses -= change
+// cacheKey identifies a prompt by its template version, the instructions
+// and schema sent with it and, for each stock in it, the indicator settings
+// and the last bar of a completed session. The live quote and today's
+// unfinished bar are left out, so reruns during the same trading day reuse
+// the cached reply.
+func cacheKey(p prompt.Prompt, instructions string, schema map[string]interface{}, inputs ...stockInput) string {
+	cal := calendar.Default()
+	lastClose := cal.LastClose(replay.Now()).Format("2006-01-02")
+	hash := sha256.New()
+	schemaJSON, _ := json.Marshal(schema) // Map keys are sorted, so this is stable
+	fmt.Fprintf(hash, "%s\x00%s\n", instructions, schemaJSON)
+	for _, input := range inputs {
+		var completed indicators.Bar
+		for _, bar := range input.History {
+			if bar.Time.In(cal.Location()).Format("2006-01-02") <= lastClose {
+				completed = bar
+			}
 		}
+		fmt.Fprintf(hash, "%s\x00%+v\x00%d %g %g %g %g %d\n", input.Entry.Symbol, input.Settings,
+			completed.Time.Unix(), completed.Open, completed.High, completed.Low, completed.Close, completed.Volume)
 	}
-
-	avgGain := gains / 14
-	avgLoss := losses / 14
-
-	if avgLoss == 0 {
-		return 100 // Perfect uptrend
-	}
-	if avgGain == 0 {
-		return 0 // Perfect downtrend
-	}
-
-	rs := avgGain / avgLoss
-	return 100 - (100 / (1 + rs))
+	return p.Version + ":" + hex.EncodeToString(hash.Sum(nil))[:16]
 }
 
-// Calculate stock metrics
-func calculateMetrics(data StockData, avgVolume int64, historicalData []StockData) StockMetrics {
-	priceChange := ((data.Price - data.PreviousClose) / data.PreviousClose) * 100
-	dailyRange := data.High - data.Low
-	volatility := (dailyRange / data.Price) * 100
-	volumeChange := ((float64(data.Volume) - float64(avgVolume)) / float64(avgVolume)) * 100
+// promptStock is what the prompt templates see of one stock
+type promptStock struct {
+	Metrics StockMetrics
+	History indicators.Series
+	Signals signals
+}
 
-	ma5, ma20 := calculateMovingAverages(historicalData)
-	priceVsMA5 := ((data.Price - ma5) / ma5) * 100
-	priceVsMA20 := ((data.Price - ma20) / ma20) * 100
+func newPromptStock(input stockInput) promptStock {
+	return promptStock{Metrics: input.Metrics, History: input.History, Signals: classify(input.Metrics, input.History)}
+}
 
-	var prices []float64
-	for _, hData := range historicalData {
-		prices = append(prices, hData.Price)
-	}
-	rsi := calculateRSI(prices)
+// buildPrompt renders the prompt asking for one stock's recommendation
+func buildPrompt(prompts *prompt.Set, input stockInput) (prompt.Prompt, error) {
+	return prompts.Render(prompt.Stock, struct {
+		promptStock
+		Instructions string
+	}{newPromptStock(input), recommend.Instructions})
+}
 
-	return StockMetrics{
-		Symbol:       data.Symbol,
-		Price:        data.Price,
-		PriceChange:  priceChange,
-		DailyRange:   dailyRange,
-		Volatility:   volatility,
-		Volume:       data.Volume,
-		VolumeChange: volumeChange,
-		MA5:          ma5,
-		MA20:         ma20,
-		PriceVsMA5:   priceVsMA5,
-		PriceVsMA20:  priceVsMA20,
-		RSI:          rsi,
+// buildBatchPrompt renders the prompt asking for a recommendation for each stock
+func buildBatchPrompt(prompts *prompt.Set, inputs []stockInput) (prompt.Prompt, error) {
+	stocks := make([]promptStock, len(inputs))
+	for i, input := range inputs {
+		stocks[i] = newPromptStock(input)
 	}
+	return prompts.Render(prompt.Batch, struct {
+		Stocks       []promptStock
+		Instructions string
+	}{stocks, recommend.BatchInstructions})
 }
 
-// Get AI insights from Gemini API
-func getGeminiInsights(metrics StockMetrics, historicalData []StockData) (string, error) {
-	client := resty.New()
+// Process every configured watchlist, generate reports and return their deliveries
+func processStocks(status calendar.Status, run *storage.Run) notify.Summary {
 	cfg := config.GetConfig()
-	if cfg.GeminiAPIKey == "" {
-		return "", fmt.Errorf("GEMINI_API_KEY not set")
-	}
 
-	// 5-day trend
-	var trend string
-	if len(historicalData) >= 5 {
-		firstPrice := historicalData[4].Price // 5th day back
-		lastPrice := historicalData[0].Price  // Most recent
-		if firstPrice > 0 {
-			trendChange := ((lastPrice - firstPrice) / firstPrice) * 100
-			if trendChange > 0 {
-				trend = fmt.Sprintf("5-day trend: 📈 +%.2f%%", trendChange)
-			} else {
-				trend = fmt.Sprintf("5-day trend: 📉 %.2f%%", trendChange)
-			}
-		}
+	// Label the report when prices are from an earlier session
+	reportDate := replay.Now().Format("02-Jan-2006")
+	if label := status.Label(); label != "" {
+		reportDate += " (" + label + ")"
 	}
 
-	rsiSignal := "Neutral"
-	if metrics.RSI > 70 {
-		rsiSignal = "Overbought"
-	} else if metrics.RSI < 30 {
-		rsiSignal = "Oversold"
-	}
-
-	maSignal := "Sideways"
-	if metrics.PriceVsMA5 > 1 && metrics.PriceVsMA20 > 1 {
-		maSignal = "Strong Uptrend"
-	} else if metrics.PriceVsMA5 > 0 && metrics.PriceVsMA20 > 0 {
-		maSignal = "Uptrend"
-	} else if metrics.PriceVsMA5 < -1 && metrics.PriceVsMA20 < -1 {
-		maSignal = "Strong Downtrend"
-	} else if metrics.PriceVsMA5 < 0 && metrics.PriceVsMA20 < 0 {
-		maSignal = "Downtrend"
-	}
+	// Process each watchlist separately, fetching each symbol once across
+	// all of them and the personal chat watchlists
+	a := newAnalyzer()
+	var summary notify.Summary
+	var classifier *marketcap.Classifier
+	for _, watchlist := range cfg.Watchlists {
+		if !watchlist.GroupByMarketCap {
+			summary.Add(processStockGroup(a, run, watchlist, reportDate)...)
+			continue
+		}
 
-	volumeSignal := "Normal Volume"
-	if metrics.VolumeChange > 50 {
-		volumeSignal = "Very High Volume"
-	} else if metrics.VolumeChange > 20 {
-		volumeSignal = "High Volume"
-	} else if metrics.VolumeChange < -50 {
-		volumeSignal = "Very Low Volume"
-	} else if metrics.VolumeChange < -20 {
-		volumeSignal = "Low Volume"
+		if classifier == nil {
+			classifier = marketcap.Default()
+		}
+		for _, group := range groupByMarketCap(classifier, watchlist) {
+			summary.Add(processStockGroup(a, run, group, reportDate)...)
+		}
 	}
 
-	prompt := fmt.Sprintf(
-		"Analyze %s stock and provide a clear trading recommendation:\n"+
-			"Current Price: ₹%.2f (%.2f%% today)\n"+
-			"%s\n"+
-			"Technical Indicators:\n"+
-			"- Price vs 5-day MA: %.2f%%\n"+
-			"- Price vs 20-day MA: %.2f%%\n"+
-			"- RSI (14): %.2f (%s)\n"+
-			"- Moving Average Trend: %s\n"+
-			"- Volume Analysis: %s\n"+
-			"- Volatility: %.2f%%\n"+
-			"Provide a clear, actionable recommendation in 2-3 lines. Include:\n"+
-			"1. Entry price and stop-loss levels\n"+
-			"2. Key resistance/support levels\n"+
-			"3. Risk level (Low/Medium/High)\n"+
-			"Be direct and decisive.",
-		metrics.Symbol, metrics.Price, metrics.PriceChange, trend,
-		metrics.PriceVsMA5, metrics.PriceVsMA20, metrics.RSI, rsiSignal,
-		maSignal, volumeSignal, metrics.Volatility,
-	)
-
-	payload := map[string]interface{}{
-		"contents": []map[string]interface{}{
-			{
-				"parts": []map[string]string{
-					{"text": prompt},
-				},
-			},
-		},
+	for _, watchlist := range chatWatchlists() {
+		summary.Add(processStockGroup(a, run, watchlist, reportDate)...)
 	}
+	return summary
+}
 
-	resp, err := client.R().
-		SetHeader("Content-Type", "application/json").
-		SetQueryParam("key", cfg.GeminiAPIKey).
-		SetBody(payload).
-		Post("https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-flash:generateContent")
-
+// chatWatchlists returns a single-chat watchlist for every authorised chat
+// that has added symbols through the bot
+func chatWatchlists() []config.Watchlist {
+	cfg := config.GetConfig()
+	lists, err := chatlist.Default().All()
 	if err != nil {
-		return "", fmt.Errorf("Gemini API request failed: %v", err)
-	}
-
-	var geminiResp GeminiResponse
-	if err := json.Unmarshal(resp.Body(), &geminiResp); err != nil {
-		return "", fmt.Errorf("failed to parse Gemini response: %v", err)
+		fmt.Printf("Warning: could not load chat watchlists: %v\n", err)
+		return nil
 	}
 
-	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no insights returned from Gemini API")
+	authorized := make(map[string]bool)
+	for _, id := range cfg.TelegramChatIDs {
+		authorized[id] = true
 	}
 
-	return geminiResp.Candidates[0].Content.Parts[0].Text, nil
-}
-
-// Process stocks and generate report
-func processStocks() {
-	cfg := config.GetConfig()
-	stocks := strings.Split(cfg.StockList, ",")
-
-	// Group stocks by market cap
-	largeCap := []string{}
-	midCap := []string{}
-	smallCap := []string{}
-
-	for _, symbol := range stocks {
-		symbol = strings.TrimSpace(symbol)
-		if symbol == "" {
+	var watchlists []config.Watchlist
+	for _, chatID := range chatlist.ChatIDs(lists) {
+		if !authorized[chatID] {
 			continue
 		}
-
-		// Categorize stocks based on the defaultStocks list
-		switch {
-		case contains(defaultStocks[:5], symbol):
-			largeCap = append(largeCap, symbol)
-		case contains(defaultStocks[5:10], symbol):
-			midCap = append(midCap, symbol)
-		case contains(defaultStocks[10:], symbol):
-			smallCap = append(smallCap, symbol)
-		default:
-			// If not in default list, assume it's a large cap
-			largeCap = append(largeCap, symbol)
+		watchlist := config.Watchlist{
+			Name:       "Your Watchlist",
+			Indicators: cfg.Indicators,
+			ChatIDs:    []string{chatID},
+			Notify:     []string{notify.TelegramNotifierName},
+		}
+		for _, symbol := range lists[chatID] {
+			watchlist.Symbols = append(watchlist.Symbols, lookupSymbol(symbol))
 		}
+		watchlists = append(watchlists, watchlist)
 	}
-
-	// Process each group separately
-	processStockGroup("Large Cap Stocks", largeCap)
-	processStockGroup("Mid Cap Stocks", midCap)
-	processStockGroup("Small Cap Stocks", smallCap)
+	return watchlists
 }
 
-// Helper function to check if a slice contains a string
-func contains(slice []string, str string) bool {
-	for _, s := range slice {
-		if s == str {
-			return true
+// groupByMarketCap splits a watchlist into one watchlist per computed
+// market-cap class, e.g. "Large Cap Stocks", in large/mid/small order
+func groupByMarketCap(classifier *marketcap.Classifier, watchlist config.Watchlist) []config.Watchlist {
+	classes := classifier.Classify(watchlist.Tickers())
+
+	var groups []config.Watchlist
+	for _, class := range marketcap.Classes {
+		group := watchlist
+		group.Name = class.Label() + " " + watchlist.Name
+		group.Category = string(class)
+		group.Symbols = nil
+		for _, entry := range watchlist.Symbols {
+			if classes[entry.Symbol].Class == class {
+				group.Symbols = append(group.Symbols, entry)
+			}
+		}
+		if len(group.Symbols) > 0 {
+			groups = append(groups, group)
 		}
 	}
-	return false
+	return groups
 }
 
-// Process a group of stocks and send notification
-func processStockGroup(groupName string, stocks []string) {
+// Process a watchlist with the given analyzer, record each stock in the run,
+// notify its chats and return the deliveries
+func processStockGroup(a *analyzer, run *storage.Run, watchlist config.Watchlist, reportDate string) []notify.Delivery {
+	stocks := watchlist.Symbols
 	if len(stocks) == 0 {
-		return
+		return nil
 	}
 
-	cfg := config.GetConfig()
-	message := fmt.Sprintf("📊 *%s* - %s\n\n", groupName, time.Now().Format("02-Jan-2006"))
+	settings := watchlist.Indicators
+	title := render.Document{render.Line{render.Plain("📊 "), render.Bold(watchlist.Name), render.Plain(" - " + reportDate)}}
+	notifiers := notify.ForNames(watchlist.Notify, watchlist.ChatIDs)
+	var deliveries []notify.Delivery
 
-	// Process stocks in groups of 5
-	for i := 0; i < len(stocks); i += 5 {
-		end := i + 5
-		if end > len(stocks) {
-			end = len(stocks)
+	// Load every stock first so a batch covers the whole watchlist
+	var inputs []stockInput
+	for _, entry := range stocks {
+		input, err := loadInput(a.data, entry, settings)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			continue
 		}
+		inputs = append(inputs, input)
+	}
+	insights := a.recommendations(inputs)
 
-		currentGroup := stocks[i:end]
-		var messages []string
-
-		for _, symbol := range currentGroup {
-			data, err := fetchYahooFinanceData(symbol)
-			if err != nil {
-				fmt.Printf("Error fetching %s: %v\n", symbol, err)
-				continue
-			}
-
-			historicalData, err := fetchHistoricalData(symbol)
-			if err != nil {
-				fmt.Printf("Error fetching historical data for %s: %v\n", symbol, err)
-				continue
-			}
-
-			var avgVolume int64
-			if len(historicalData) > 0 {
-				totalVolume := int64(0)
-				for _, hData := range historicalData {
-					totalVolume += hData.Volume
-				}
-				avgVolume = totalVolume / int64(len(historicalData))
-			}
-
-			metrics := calculateMetrics(data, avgVolume, historicalData)
-			insights, err := getGeminiInsights(metrics, historicalData)
-			if err != nil {
-				fmt.Printf("Error getting insights for %s: %v\n", symbol, err)
-				continue
-			}
-
-			priceChangeEmoji := "📈"
-			if metrics.PriceChange < 0 {
-				priceChangeEmoji = "📉"
-			}
+	// Send the reports in messages of 5 stocks
+	for i := 0; i < len(inputs); i += 5 {
+		end := i + 5
+		if end > len(inputs) {
+			end = len(inputs)
+		}
 
-			volumeChangeEmoji := "📊"
-			if metrics.VolumeChange > 20 {
-				volumeChangeEmoji = "🚀"
-			} else if metrics.VolumeChange < -20 {
-				volumeChangeEmoji = "📉"
+		var messages []render.Document
+		var charts []notify.Image
+		for j := i; j < end; j++ {
+			input := inputs[j]
+			record(run, input, insights[j])
+			stockReport := a.report(input, insights[j], watchlist.ChartsEnabled())
+			messages = append(messages, stockReport.Card)
+			if stockReport.Chart != nil {
+				charts = append(charts, *stockReport.Chart)
 			}
-
-			companyName := strings.TrimSuffix(symbol, ".NS")
-			technicalIndicators := fmt.Sprintf(
-				"Price vs 5-day MA: %.2f%%\n"+
-					"Price vs 20-day MA: %.2f%%\n"+
-					"RSI (14): %.2f\n"+
-					"Volatility: %.2f%%",
-				metrics.PriceVsMA5, metrics.PriceVsMA20, metrics.RSI, metrics.Volatility,
-			)
-
-			stockMessage := fmt.Sprintf(
-				"*%s* (%s)\n"+
-					"💰 *Price*: ₹%.2f %s (*%.2f%%*)\n"+
-					"📈 *Volume*: %s %.2f%% vs avg\n"+
-					"📊 *Technical Indicators*:\n```\n%s\n```\n"+
-					"🤖 *AI Insights*:\n%s\n",
-				companyName, symbol, data.Price, priceChangeEmoji, metrics.PriceChange,
-				volumeChangeEmoji, metrics.VolumeChange, technicalIndicators, insights,
-			)
-			messages = append(messages, stockMessage)
 		}
 
 		if len(messages) > 0 {
-			groupMessage := message + strings.Join(messages, "\n---\n")
-			fmt.Println(groupMessage)
+			report := notify.Message{Title: title, Sections: messages, Images: charts}
+			fmt.Println(report.Text())
 
-			if cfg.TelegramBotToken != "" && len(cfg.TelegramChatIDs) > 0 {
-				sendStockTelegramNotification(groupMessage)
-			}
+			deliveries = append(deliveries, notify.SendAll(notifiers, report)...)
 		}
 	}
+	return deliveries
 }
 
-// RunStockAnalysis executes the analysis
-func RunStockAnalysis() {
+// RunStockAnalysis executes the analysis, recording each stock in run, and
+// returns what was delivered
+func RunStockAnalysis(run *storage.Run) notify.Summary {
 	fmt.Println("Running stock analysis...")
-	processStocks()
+
+	cfg := config.GetConfig()
+	status := calendar.Default().StatusAt(replay.Now())
+	if !status.TradingDay && cfg.MarketClosedPolicy == "skip" {
+		fmt.Printf("Market closed today (%s), skipping stock analysis\n", status.Reason)
+		return notify.Summary{}
+	}
+
+	return processStocks(status, run)
+}
+
+// record adds a stock's quote, metrics and recommendation to the run,
+// dated by the session the prices are from. A symbol reported in several
+// groups, such as two watchlists or chats, is recorded once per run, from
+// the first group that reported it.
+func record(run *storage.Run, input stockInput, in insight) {
+	symbol := input.Entry.Symbol
+	date := storage.Date(input.History.Last().Time)
+	if run.HasRecommendation(symbol, date) {
+		return
+	}
+	run.AddQuote(storage.Quote{
+		Symbol:        symbol,
+		Date:          date,
+		Price:         input.Quote.Price,
+		PreviousClose: input.Quote.PreviousClose,
+		High:          input.Quote.High,
+		Low:           input.Quote.Low,
+		Volume:        input.Quote.Volume,
+	})
+	run.AddMetrics(storage.Metrics{Symbol: symbol, Date: date, Values: metricsValues(input.Metrics)})
+	run.AddRecommendation(storage.Recommendation{
+		Symbol:         symbol,
+		Date:           date,
+		IssuedAt:       replay.Now(),
+		Price:          input.Metrics.Price,
+		Source:         in.Source,
+		PromptVersion:  in.PromptVersion,
+		Recommendation: in.Recommendation,
+	})
 }
 
-// Helper function to get minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
+// metricsValues names each computed metric for storage
+func metricsValues(m StockMetrics) map[string]float64 {
+	return map[string]float64{
+		"price":             m.Price,
+		"price_change":      m.PriceChange,
+		"daily_range":       m.DailyRange,
+		"volatility":        m.Volatility,
+		"volume":            float64(m.Volume),
+		"volume_change":     m.VolumeChange,
+		"ma_short":          m.MAShort,
+		"ma_long":           m.MALong,
+		"price_vs_ma_short": m.PriceVsMAShort,
+		"price_vs_ma_long":  m.PriceVsMALong,
+		"rsi":               m.RSI,
+		"ema":               m.EMA,
+		"macd":              m.MACD,
+		"macd_signal":       m.MACDSignal,
+		"macd_histogram":    m.MACDHistogram,
+		"bollinger_upper":   m.BollingerUpper,
+		"bollinger_mid":     m.BollingerMid,
+		"bollinger_lower":   m.BollingerLower,
+		"percent_b":         m.PercentB,
+		"atr":               m.ATR,
+		"stochastic_k":      m.StochasticK,
+		"stochastic_d":      m.StochasticD,
+		"adx":               m.ADX,
+		"plus_di":           m.PlusDI,
+		"minus_di":          m.MinusDI,
 	}
-	return b
 }
@@ -0,0 +1,181 @@
+package stock
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"go-stock/replay"
+)
+
+const yahooChartURL = "https://query1.finance.yahoo.com/v8/finance/chart/%s"
+
+// YahooProvider fetches market data from the Yahoo Finance chart API
+type YahooProvider struct {
+	client *resty.Client
+	policy MissingBarPolicy
+}
+
+// NewYahooProvider creates a Yahoo Finance provider with retrying HTTP client.
+// policy decides how bars with a null close are handled.
+func NewYahooProvider(policy MissingBarPolicy) *YahooProvider {
+	client := resty.New().
+		SetRetryCount(3).                    // Retry on failure
+		SetRetryWaitTime(2*time.Second).     // Initial wait
+		SetRetryMaxWaitTime(10*time.Second). // Max wait
+		SetHeader("User-Agent", "Mozilla/5.0").
+		SetTransport(replay.Transport())
+
+	if policy != ForwardFillMissingBars {
+		policy = DropMissingBars
+	}
+
+	return &YahooProvider{client: client, policy: policy}
+}
+
+// yahooChartResponse mirrors the parts of the chart API response we use
+type yahooChartResponse struct {
+	Chart struct {
+		Result []struct {
+			Meta struct {
+				RegularMarketPrice   float64 `json:"regularMarketPrice"`
+				PreviousClose        float64 `json:"previousClose"`
+				RegularMarketDayHigh float64 `json:"regularMarketDayHigh"`
+				RegularMarketDayLow  float64 `json:"regularMarketDayLow"`
+				RegularMarketVolume  int64   `json:"regularMarketVolume"`
+			} `json:"meta"`
+			Timestamp  []int64 `json:"timestamp"`
+			Indicators struct {
+				// Entries are null on holidays and partial sessions
+				Quote []struct {
+					Open   []*float64 `json:"open"`
+					Close  []*float64 `json:"close"`
+					High   []*float64 `json:"high"`
+					Low    []*float64 `json:"low"`
+					Volume []*int64   `json:"volume"`
+				} `json:"quote"`
+			} `json:"indicators"`
+		} `json:"result"`
+	} `json:"chart"`
+}
+
+// Quote fetches current stock data from Yahoo Finance
+func (p *YahooProvider) Quote(symbol string) (StockData, error) {
+	resp, err := p.client.R().
+		Get(fmt.Sprintf(yahooChartURL, symbol))
+
+	if err != nil {
+		return StockData{}, fmt.Errorf("failed to fetch data for %s: %v", symbol, err)
+	}
+
+	var result yahooChartResponse
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		return StockData{}, fmt.Errorf("failed to parse response for %s: %v", symbol, err)
+	}
+
+	if len(result.Chart.Result) == 0 {
+		return StockData{}, fmt.Errorf("no data found for symbol %s", symbol)
+	}
+
+	meta := result.Chart.Result[0].Meta
+
+	// Validate critical fields
+	if meta.RegularMarketPrice <= 0 || meta.PreviousClose <= 0 {
+		return StockData{}, fmt.Errorf("invalid price data for %s: price=%.2f, previousClose=%.2f", symbol, meta.RegularMarketPrice, meta.PreviousClose)
+	}
+
+	return StockData{
+		Symbol:        symbol,
+		Price:         meta.RegularMarketPrice,
+		PreviousClose: meta.PreviousClose,
+		High:          meta.RegularMarketDayHigh,
+		Low:           meta.RegularMarketDayLow,
+		Volume:        meta.RegularMarketVolume,
+	}, nil
+}
+
+// DailyHistory fetches daily bars for the last `days` calendar days
+func (p *YahooProvider) DailyHistory(symbol string, days int) (History, error) {
+	endTime := replay.Now()
+	startTime := endTime.AddDate(0, 0, -days)
+
+	return p.fetchHistory(symbol, map[string]string{
+		"period1":  fmt.Sprintf("%d", startTime.Unix()),
+		"period2":  fmt.Sprintf("%d", endTime.Unix()),
+		"interval": "1d",
+	})
+}
+
+// IntradayHistory fetches intraday bars for the latest session
+func (p *YahooProvider) IntradayHistory(symbol string, interval string) (History, error) {
+	return p.fetchHistory(symbol, map[string]string{
+		"range":    "1d",
+		"interval": interval,
+	})
+}
+
+// fetchHistory queries the chart API and flattens the quote arrays into bars
+func (p *YahooProvider) fetchHistory(symbol string, params map[string]string) (History, error) {
+	resp, err := p.client.R().
+		SetQueryParams(params).
+		Get(fmt.Sprintf(yahooChartURL, symbol))
+
+	if err != nil {
+		return History{}, fmt.Errorf("failed to fetch historical data for %s: %v", symbol, err)
+	}
+
+	var result yahooChartResponse
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		return History{}, fmt.Errorf("failed to parse historical response for %s: %v", symbol, err)
+	}
+
+	if len(result.Chart.Result) == 0 || len(result.Chart.Result[0].Indicators.Quote) == 0 {
+		return History{}, fmt.Errorf("no historical data found for symbol %s", symbol)
+	}
+
+	chart := result.Chart.Result[0]
+	quote := chart.Indicators.Quote[0]
+
+	var warnings []string
+	for name, n := range map[string]int{"open": len(quote.Open), "high": len(quote.High), "low": len(quote.Low), "close": len(quote.Close), "volume": len(quote.Volume)} {
+		if n != len(chart.Timestamp) {
+			warnings = append(warnings, fmt.Sprintf("%s has %d values for %d timestamps", name, n, len(chart.Timestamp)))
+		}
+	}
+	sort.Strings(warnings)
+
+	// Arrays of different lengths are treated as missing values past their end
+	raw := make([]rawBar, len(chart.Timestamp))
+	for i, ts := range chart.Timestamp {
+		raw[i] = rawBar{
+			Time:   time.Unix(ts, 0),
+			Open:   floatAt(quote.Open, i),
+			High:   floatAt(quote.High, i),
+			Low:    floatAt(quote.Low, i),
+			Close:  floatAt(quote.Close, i),
+			Volume: intAt(quote.Volume, i),
+		}
+	}
+
+	bars, cleanWarnings := cleanBars(raw, p.policy)
+	return History{Bars: bars, Warnings: append(warnings, cleanWarnings...)}, nil
+}
+
+// floatAt returns values[i], or nil when the array is too short
+func floatAt(values []*float64, i int) *float64 {
+	if i >= len(values) {
+		return nil
+	}
+	return values[i]
+}
+
+// intAt returns values[i], or nil when the array is too short
+func intAt(values []*int64, i int) *int64 {
+	if i >= len(values) {
+		return nil
+	}
+	return values[i]
+}
@@ -0,0 +1,165 @@
+package stock
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-stock/config"
+	"go-stock/indicators"
+	"go-stock/notify"
+	"go-stock/storage"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/report.golden from the current output")
+
+// TestMain replays the fixtures in testdata/fixtures, recorded on
+// 11-Jun-2025 after the close, so no test touches the network
+func TestMain(m *testing.M) {
+	for _, key := range []string{"STOCK_LIST", "LLM_PROVIDER", "LLM_MODEL", "LLM_BASE_URL", "LLM_API_KEY", "LLM_BATCH", "INSIGHTS_MODE", "PROMPT_DIR", "TELEGRAM_PARSE_MODE", "MISSING_BAR_POLICY", "MARKET_HOLIDAYS_FILE"} {
+		os.Unsetenv(key)
+	}
+	os.Setenv("HTTP_MODE", "replay")
+	os.Setenv("HTTP_FIXTURE_DIR", filepath.Join("testdata", "fixtures"))
+	os.Setenv("GEMINI_API_KEY", "test-key")
+	os.Setenv("TELEGRAM_BOT_TOKEN", "test-token")
+	os.Setenv("TELEGRAM_CHAT_IDS", "1000")
+	os.Exit(m.Run())
+}
+
+// TestProcessStockGroup runs a two-stock watchlist end to end against the
+// fixtures: Yahoo quotes and history, one Gemini reply that is accepted and
+// one that is rejected in favour of the rule-based outlook, and the
+// Telegram delivery. The fixtures are keyed by request body, so the
+// delivery only succeeds if the report renders exactly as recorded.
+func TestProcessStockGroup(t *testing.T) {
+	charts := false
+	watchlist := config.Watchlist{
+		Name: "Test Watchlist",
+		Symbols: []config.WatchlistSymbol{
+			{Symbol: "TCS.NS", Name: "Tata Consultancy Services"},
+			{Symbol: "INFY.NS"},
+		},
+		Indicators: config.GetConfig().Indicators,
+		ChatIDs:    []string{"1000"},
+		Notify:     []string{"telegram"},
+		Charts:     &charts,
+	}
+
+	run := storage.NewRun("stock")
+	var deliveries []string
+	output := captureStdout(t, func() {
+		for _, d := range processStockGroup(newAnalyzer(), run, watchlist, "11-Jun-2025") {
+			for _, result := range d.Results {
+				if result.Err != nil {
+					t.Errorf("delivery to %s failed: %v", result.Target, result.Err)
+				}
+				deliveries = append(deliveries, result.Target)
+			}
+		}
+	})
+	if len(deliveries) != 1 {
+		t.Errorf("got %d deliveries, want 1", len(deliveries))
+	}
+
+	golden := filepath.Join("testdata", "report.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(output), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != string(want) {
+		t.Errorf("report differs from %s (rerun with -update to accept):\n%s", golden, output)
+	}
+
+	// Both stocks are recorded, each with the source that wrote its call
+	db, err := storage.Open(filepath.Join(t.TempDir(), "stock.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	run.Finish(notify.Summary{})
+	if err := db.SaveRun(run); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := db.Recommendations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := make(map[string]string)
+	for _, rec := range recs {
+		if rec.Date != "2025-06-11" {
+			t.Errorf("%s recorded for %s, want 2025-06-11", rec.Symbol, rec.Date)
+		}
+		sources[rec.Symbol] = rec.Source
+	}
+	if got := sources["TCS.NS"]; got != "gemini/gemini-2.5-flash" {
+		t.Errorf("TCS.NS source = %q, want the model", got)
+	}
+	if got := sources["INFY.NS"]; got != rulesSource {
+		t.Errorf("INFY.NS source = %q, want %q", got, rulesSource)
+	}
+}
+
+// captureStdout returns what fn prints
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan string)
+	go func() {
+		var sb strings.Builder
+		io.Copy(&sb, r)
+		done <- sb.String()
+	}()
+
+	defer func() { os.Stdout = stdout }()
+	fn()
+	w.Close()
+	return <-done
+}
+
+// TestRecordOnce checks that a symbol reported in two groups of the same
+// run keeps the first group's recommendation
+func TestRecordOnce(t *testing.T) {
+	input := stockInput{
+		Entry:   config.WatchlistSymbol{Symbol: "TCS.NS"},
+		Quote:   StockData{Symbol: "TCS.NS", Price: 3686},
+		Metrics: StockMetrics{Symbol: "TCS.NS", Price: 3686},
+		History: indicators.Series{{Time: time.Date(2025, 6, 11, 9, 15, 0, 0, time.UTC), Close: 3686}},
+	}
+
+	run := storage.NewRun("stock")
+	record(run, input, insight{Source: "gemini/gemini-2.5-flash"})
+	input.Metrics.Price = 3690
+	record(run, input, insight{Source: rulesSource})
+	run.Finish(notify.Summary{})
+
+	db, err := storage.Open(filepath.Join(t.TempDir(), "stock.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.SaveRun(run); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := db.Recommendations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 || recs[0].Source != "gemini/gemini-2.5-flash" || recs[0].Price != 3686 {
+		t.Errorf("recorded %+v, want only the first group's recommendation", recs)
+	}
+}
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"go-stock/notify"
+	"go-stock/recommend"
+	"go-stock/replay"
+)
+
+// exchangeZone dates records in exchange time
+var exchangeZone = loadExchangeZone()
+
+func loadExchangeZone() *time.Location {
+	location, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		return time.FixedZone("IST", 5*60*60+30*60)
+	}
+	return location
+}
+
+// Date formats t as the exchange-local date used in record keys
+func Date(t time.Time) string {
+	return t.In(exchangeZone).Format("2006-01-02")
+}
+
+// Run is one execution of a task. It collects what the task produced and
+// is written in one go by Save once the task has finished. A nil *Run
+// records nothing, for callers such as the bot that are not task runs.
+type Run struct {
+	ID         string     `json:"id"`
+	Task       string     `json:"task"`
+	Started    time.Time  `json:"started"`
+	Finished   time.Time  `json:"finished"`
+	Deliveries []Delivery `json:"deliveries"`
+
+	quotes          []Quote
+	metrics         []Metrics
+	recommendations []Recommendation
+	indexReturns    []IndexReturn
+}
+
+// Quote is a symbol's quote on one trading day
+type Quote struct {
+	Symbol        string  `json:"symbol"`
+	Date          string  `json:"date"`
+	Price         float64 `json:"price"`
+	PreviousClose float64 `json:"previous_close"`
+	High          float64 `json:"high"`
+	Low           float64 `json:"low"`
+	Volume        int64   `json:"volume"`
+	RunID         string  `json:"run_id"`
+}
+
+// Metrics are a symbol's computed indicators on one trading day, by name
+type Metrics struct {
+	Symbol string             `json:"symbol"`
+	Date   string             `json:"date"`
+	Values map[string]float64 `json:"values"`
+	RunID  string             `json:"run_id"`
+}
+
+// Recommendation is a trading call as issued, with the price at the time
+type Recommendation struct {
+	Symbol         string                   `json:"symbol"`
+	Date           string                   `json:"date"`
+	IssuedAt       time.Time                `json:"issued_at"`
+	Price          float64                  `json:"price"`
+	Source         string                   `json:"source"` // "provider/model" or "rules"
+	PromptVersion  string                   `json:"prompt_version,omitempty"`
+	Recommendation recommend.Recommendation `json:"recommendation"`
+	RunID          string                   `json:"run_id"`
+	Outcome        *Outcome                 `json:"outcome,omitempty"` // nil until first evaluated
+}
+
+// Outcome statuses of a BUY or SELL recommendation
+const (
+	StatusOpen      = "open"       // Neither target nor stop-loss reached yet
+	StatusTargetHit = "target_hit" // Reached its first target
+	StatusStopHit   = "stop_hit"   // Reached its stop-loss
+	StatusExpired   = "expired"    // Reached neither within the evaluation window
+)
+
+// Outcome is how a recommendation has played out in the sessions since it was issued
+type Outcome struct {
+	Status    string    `json:"status"`
+	Date      string    `json:"date,omitempty"` // Trading date it resolved on, empty while open
+	Exit      float64   `json:"exit"`           // Target or stop-loss price, or the latest close
+	Return    float64   `json:"return"`         // Percent return from entry, positive when the call was right
+	Sessions  int       `json:"sessions"`       // Sessions evaluated since issue
+	Evaluated time.Time `json:"evaluated"`
+}
+
+// Resolved reports whether the outcome is final
+func (o *Outcome) Resolved() bool {
+	return o != nil && o.Status != StatusOpen
+}
+
+// IndexReturn is an index's return over the week ending on Date
+type IndexReturn struct {
+	Index     string  `json:"index"`
+	Date      string  `json:"date"`
+	StartDate string  `json:"start_date"`
+	EndDate   string  `json:"end_date"`
+	Return    float64 `json:"return"`
+	RunID     string  `json:"run_id"`
+}
+
+// Delivery is the outcome of sending one message (or part) to one target
+type Delivery struct {
+	Notifier string `json:"notifier"`
+	Target   string `json:"target"`
+	Part     int    `json:"part,omitempty"`
+	Parts    int    `json:"parts,omitempty"`
+	Note     string `json:"note,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// NewRun starts recording a run of the named task
+func NewRun(task string) *Run {
+	started := replay.Now()
+	return &Run{
+		ID:      started.UTC().Format("20060102T150405.000000000Z") + "-" + task,
+		Task:    task,
+		Started: started,
+	}
+}
+
+// AddQuote records a quote
+func (r *Run) AddQuote(q Quote) {
+	if r == nil {
+		return
+	}
+	q.RunID = r.ID
+	r.quotes = append(r.quotes, q)
+}
+
+// AddMetrics records a symbol's metrics. Values that are not finite numbers
+// are left out, since JSON cannot hold them.
+func (r *Run) AddMetrics(m Metrics) {
+	if r == nil {
+		return
+	}
+	values := make(map[string]float64, len(m.Values))
+	for name, v := range m.Values {
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			values[name] = v
+		}
+	}
+	m.Values = values
+	m.RunID = r.ID
+	r.metrics = append(r.metrics, m)
+}
+
+// AddRecommendation records a recommendation
+func (r *Run) AddRecommendation(rec Recommendation) {
+	if r == nil {
+		return
+	}
+	rec.RunID = r.ID
+	r.recommendations = append(r.recommendations, rec)
+}
+
+// HasRecommendation reports whether the run already holds a recommendation
+// for symbol on date
+func (r *Run) HasRecommendation(symbol, date string) bool {
+	if r == nil {
+		return false
+	}
+	for _, rec := range r.recommendations {
+		if rec.Symbol == symbol && rec.Date == date {
+			return true
+		}
+	}
+	return false
+}
+
+// AddIndexReturn records an index return
+func (r *Run) AddIndexReturn(ret IndexReturn) {
+	if r == nil {
+		return
+	}
+	ret.RunID = r.ID
+	r.indexReturns = append(r.indexReturns, ret)
+}
+
+// Finish records the end of the run and the status of every delivery
+func (r *Run) Finish(summary notify.Summary) {
+	if r == nil {
+		return
+	}
+	r.Finished = replay.Now()
+	for _, d := range summary.Deliveries {
+		for _, result := range d.Results {
+			delivery := Delivery{
+				Notifier: d.Notifier,
+				Target:   result.Target,
+				Part:     result.Part,
+				Parts:    result.Parts,
+				Note:     result.Note,
+			}
+			if result.Err != nil {
+				delivery.Error = result.Err.Error()
+			}
+			r.Deliveries = append(r.Deliveries, delivery)
+		}
+	}
+}
+
+// Save writes a finished run to the configured store
+func Save(run *Run) error {
+	if run == nil {
+		return nil
+	}
+	db, err := Default()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := db.SaveRun(run); err != nil {
+		return fmt.Errorf("saving run %s: %v", run.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+// Package storage keeps a history of every run on disk: daily quotes,
+// computed metrics, recommendations, market fall index returns and the
+// delivery status of each run, in a single bbolt file.
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"go-stock/config"
+)
+
+// openTimeout bounds the wait for another process (e.g. the daemon) to
+// release the file lock
+const openTimeout = 10 * time.Second
+
+// Buckets, each holding JSON records
+var (
+	bucketMeta            = []byte("meta")
+	bucketRuns            = []byte("runs")            // Run ID -> Run
+	bucketQuotes          = []byte("quotes")          // SYMBOL/YYYY-MM-DD -> Quote
+	bucketMetrics         = []byte("metrics")         // SYMBOL/YYYY-MM-DD -> Metrics
+	bucketRecommendations = []byte("recommendations") // SYMBOL/YYYY-MM-DD -> Recommendation
+	bucketIndexReturns    = []byte("index_returns")   // Index/YYYY-MM-DD -> IndexReturn
+)
+
+// schemaVersionKey holds the number of migrations applied, in the meta bucket
+var schemaVersionKey = []byte("schema_version")
+
+// migrations upgrade the file one schema version at a time; migration i
+// brings it to version i+1. Only ever append: released migrations must not
+// change, since existing files have already run them.
+var migrations = []func(tx *bolt.Tx) error{
+	// 1: runs, quotes, metrics, recommendations and index returns
+	func(tx *bolt.Tx) error {
+		return createBuckets(tx, bucketRuns, bucketQuotes, bucketMetrics, bucketRecommendations, bucketIndexReturns)
+	},
+}
+
+// DB is an open store
+type DB struct {
+	bolt *bolt.DB
+}
+
+// Open opens or creates the store at path and applies any pending migrations
+func Open(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %v", path, err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating %s: %v", path, err)
+	}
+	return &DB{bolt: db}, nil
+}
+
+// Default opens the store at the configured path
+func Default() (*DB, error) {
+	return Open(config.GetConfig().StoreFile)
+}
+
+// Close releases the file
+func (d *DB) Close() error {
+	return d.bolt.Close()
+}
+
+// migrate applies the migrations the file has not seen yet, each in its own transaction
+func migrate(db *bolt.DB) error {
+	version, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than this build supports (%d)", version, len(migrations))
+	}
+	for i := version; i < len(migrations); i++ {
+		err := db.Update(func(tx *bolt.Tx) error {
+			if err := migrations[i](tx); err != nil {
+				return err
+			}
+			meta, err := tx.CreateBucketIfNotExists(bucketMeta)
+			if err != nil {
+				return err
+			}
+			return meta.Put(schemaVersionKey, []byte(strconv.Itoa(i+1)))
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// schemaVersion returns the number of migrations applied, 0 for a new file
+func schemaVersion(db *bolt.DB) (int, error) {
+	version := 0
+	err := db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(bucketMeta)
+		if meta == nil {
+			return nil
+		}
+		value := meta.Get(schemaVersionKey)
+		if value == nil {
+			return nil
+		}
+		var err error
+		version, err = strconv.Atoi(string(value))
+		return err
+	})
+	return version, err
+}
+
+func createBuckets(tx *bolt.Tx, names ...[]byte) error {
+	for _, name := range names {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveRun writes a finished run and everything it collected in one
+// transaction. Records for a symbol and date already stored, e.g. by an
+// earlier run the same day, are replaced.
+func (d *DB) SaveRun(run *Run) error {
+	return d.bolt.Update(func(tx *bolt.Tx) error {
+		if err := put(tx, bucketRuns, run.ID, run); err != nil {
+			return err
+		}
+		for _, q := range run.quotes {
+			if err := put(tx, bucketQuotes, dayKey(q.Symbol, q.Date), q); err != nil {
+				return err
+			}
+		}
+		for _, m := range run.metrics {
+			if err := put(tx, bucketMetrics, dayKey(m.Symbol, m.Date), m); err != nil {
+				return err
+			}
+		}
+		for _, r := range run.recommendations {
+			if err := put(tx, bucketRecommendations, dayKey(r.Symbol, r.Date), r); err != nil {
+				return err
+			}
+		}
+		for _, r := range run.indexReturns {
+			if err := put(tx, bucketIndexReturns, dayKey(r.Index, r.Date), r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SaveRecommendations writes back recommendations, e.g. with updated outcomes
+func (d *DB) SaveRecommendations(recs []Recommendation) error {
+	return d.bolt.Update(func(tx *bolt.Tx) error {
+		for _, r := range recs {
+			if err := put(tx, bucketRecommendations, dayKey(r.Symbol, r.Date), r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Runs returns every stored run, oldest first
+func (d *DB) Runs() ([]Run, error) {
+	var runs []Run
+	return runs, d.scan(bucketRuns, "", func(data []byte) error {
+		var run Run
+		if err := json.Unmarshal(data, &run); err != nil {
+			return err
+		}
+		runs = append(runs, run)
+		return nil
+	})
+}
+
+// Quotes returns the stored daily quotes for a symbol, oldest first
+func (d *DB) Quotes(symbol string) ([]Quote, error) {
+	var quotes []Quote
+	return quotes, d.scan(bucketQuotes, symbol+"/", func(data []byte) error {
+		var q Quote
+		if err := json.Unmarshal(data, &q); err != nil {
+			return err
+		}
+		quotes = append(quotes, q)
+		return nil
+	})
+}
+
+// Metrics returns the stored daily metrics for a symbol, oldest first
+func (d *DB) Metrics(symbol string) ([]Metrics, error) {
+	var metrics []Metrics
+	return metrics, d.scan(bucketMetrics, symbol+"/", func(data []byte) error {
+		var m Metrics
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		metrics = append(metrics, m)
+		return nil
+	})
+}
+
+// Recommendations returns every stored recommendation, by symbol then date
+func (d *DB) Recommendations() ([]Recommendation, error) {
+	var recs []Recommendation
+	return recs, d.scan(bucketRecommendations, "", func(data []byte) error {
+		var r Recommendation
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+		recs = append(recs, r)
+		return nil
+	})
+}
+
+// IndexReturns returns the stored weekly returns of an index, oldest first
+func (d *DB) IndexReturns(index string) ([]IndexReturn, error) {
+	var returns []IndexReturn
+	return returns, d.scan(bucketIndexReturns, index+"/", func(data []byte) error {
+		var r IndexReturn
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+		returns = append(returns, r)
+		return nil
+	})
+}
+
+// put stores value as JSON under key
+func put(tx *bolt.Tx, bucket []byte, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encoding %s %s: %v", bucket, key, err)
+	}
+	return tx.Bucket(bucket).Put([]byte(key), data)
+}
+
+// scan calls fn with each value whose key starts with prefix, in key order
+func (d *DB) scan(bucket []byte, prefix string, fn func(data []byte) error) error {
+	return d.bolt.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+		p := []byte(prefix)
+		for k, v := c.Seek(p); k != nil && hasPrefix(k, p); k, v = c.Next() {
+			if err := fn(v); err != nil {
+				return fmt.Errorf("decoding %s %s: %v", bucket, k, err)
+			}
+		}
+		return nil
+	})
+}
+
+// dayKey orders records by name, then date
+func dayKey(name, date string) string {
+	return name + "/" + date
+}
+
+func hasPrefix(key, prefix []byte) bool {
+	return len(key) >= len(prefix) && string(key[:len(prefix)]) == string(prefix)
+}
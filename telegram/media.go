@@ -0,0 +1,121 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"sort"
+)
+
+// MaxMediaGroup is the most photos Telegram accepts in one album
+const MaxMediaGroup = 10
+
+// Photo is an image to upload, with an optional plain-text caption
+type Photo struct {
+	Name    string // File name, e.g. "RELIANCE.NS.png"
+	Data    []byte
+	Caption string
+}
+
+// SendPhoto uploads a single photo to a chat
+func (c *Client) SendPhoto(chatID string, photo Photo) error {
+	fields := map[string]string{"chat_id": chatID}
+	if photo.Caption != "" {
+		fields["caption"] = photo.Caption
+	}
+	contentType, body, err := multipartBody(fields, map[string]Photo{"photo": photo})
+	if err != nil {
+		return err
+	}
+
+	return c.callWithRetry(chatID, func() error {
+		return c.post(context.Background(), "sendPhoto", contentType, body, nil)
+	})
+}
+
+// SendMediaGroup uploads 2 to MaxMediaGroup photos to a chat as one album
+func (c *Client) SendMediaGroup(chatID string, photos []Photo) error {
+	if len(photos) < 2 || len(photos) > MaxMediaGroup {
+		return fmt.Errorf("a media group needs 2 to %d photos, got %d", MaxMediaGroup, len(photos))
+	}
+
+	type inputMedia struct {
+		Type    string `json:"type"`
+		Media   string `json:"media"`
+		Caption string `json:"caption,omitempty"`
+	}
+	media := make([]inputMedia, len(photos))
+	files := make(map[string]Photo, len(photos))
+	for i, photo := range photos {
+		field := fmt.Sprintf("photo%d", i)
+		media[i] = inputMedia{Type: "photo", Media: "attach://" + field, Caption: photo.Caption}
+		files[field] = photo
+	}
+	mediaJSON, err := json.Marshal(media)
+	if err != nil {
+		return err
+	}
+
+	contentType, body, err := multipartBody(map[string]string{"chat_id": chatID, "media": string(mediaJSON)}, files)
+	if err != nil {
+		return err
+	}
+
+	return c.callWithRetry(chatID, func() error {
+		return c.post(context.Background(), "sendMediaGroup", contentType, body, nil)
+	})
+}
+
+// multipartBody encodes form fields and file uploads as multipart/form-data.
+// Parts are written in sorted order with a boundary derived from the content,
+// so the same upload always encodes identically and can be replayed.
+func multipartBody(fields map[string]string, files map[string]Photo) (string, []byte, error) {
+	h := sha256.New()
+	fieldKeys := sortedKeys(fields)
+	for _, key := range fieldKeys {
+		fmt.Fprintf(h, "%s=%s\n", key, fields[key])
+	}
+	fileKeys := sortedKeys(files)
+	for _, field := range fileKeys {
+		h.Write(files[field].Data)
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := w.SetBoundary(hex.EncodeToString(h.Sum(nil))[:40]); err != nil {
+		return "", nil, err
+	}
+	for _, key := range fieldKeys {
+		if err := w.WriteField(key, fields[key]); err != nil {
+			return "", nil, err
+		}
+	}
+	for _, field := range fileKeys {
+		photo := files[field]
+		part, err := w.CreateFormFile(field, photo.Name)
+		if err != nil {
+			return "", nil, err
+		}
+		if _, err := part.Write(photo.Data); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", nil, err
+	}
+	return w.FormDataContentType(), buf.Bytes(), nil
+}
+
+// sortedKeys returns a map's keys in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,69 @@
+package telegram
+
+import (
+	"sync"
+	"time"
+
+	"go-stock/replay"
+)
+
+// Telegram's documented limits for bots
+const (
+	perChatInterval = time.Second      // 1 message per second to the same chat
+	globalInterval  = time.Second / 30 // 30 messages per second overall
+)
+
+// rateLimiter spaces out messages so a bot stays within Telegram's limits
+type rateLimiter struct {
+	mu         sync.Mutex
+	nextGlobal time.Time
+	nextChat   map[string]time.Time
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   = make(map[string]*rateLimiter)
+)
+
+// limiterFor returns the shared limiter for a bot token
+func limiterFor(token string) *rateLimiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	l, ok := limiters[token]
+	if !ok {
+		l = &rateLimiter{nextChat: make(map[string]time.Time)}
+		limiters[token] = l
+	}
+	return l
+}
+
+// wait blocks until a message may be sent to chatID and reserves the slot.
+// Replayed runs never touch the network, so they are not limited.
+func (l *rateLimiter) wait(chatID string) {
+	if replay.Mode() == replay.ModeReplay {
+		return
+	}
+
+	l.mu.Lock()
+	at := time.Now()
+	if l.nextGlobal.After(at) {
+		at = l.nextGlobal
+	}
+	if next := l.nextChat[chatID]; next.After(at) {
+		at = next
+	}
+	l.nextGlobal = at.Add(globalInterval)
+	l.nextChat[chatID] = at.Add(perChatInterval)
+	l.mu.Unlock()
+
+	time.Sleep(time.Until(at))
+}
+
+// hold stops messages to chatID for d, e.g. after a 429
+func (l *rateLimiter) hold(chatID string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.nextChat[chatID]) {
+		l.nextChat[chatID] = until
+	}
+}
@@ -0,0 +1,206 @@
+// Package telegram is a minimal client for the Telegram Bot API.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-stock/replay"
+)
+
+const apiURL = "https://api.telegram.org/bot%s/%s"
+
+// Retry policy for failed requests. Network errors and 5xx responses are
+// retried with exponential backoff; 429 responses wait for retry_after.
+const (
+	maxAttempts = 4
+	baseBackoff = 1 * time.Second
+	maxBackoff  = 30 * time.Second
+)
+
+// Client calls Bot API methods with a single bot token
+type Client struct {
+	token   string
+	http    *http.Client
+	limiter *rateLimiter
+}
+
+// NewClient creates a client for the given bot token. Clients for the same
+// token share one rate limiter.
+func NewClient(token string) *Client {
+	return &Client{
+		token:   token,
+		http:    replay.Client(30 * time.Second),
+		limiter: limiterFor(token),
+	}
+}
+
+// APIError is returned when Telegram rejects a request
+type APIError struct {
+	StatusCode  int
+	Description string
+	RetryAfter  time.Duration // Wait requested by Telegram on 429, if any
+}
+
+// retryable reports whether the request may succeed if sent again
+func (e *APIError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+func (e *APIError) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("telegram API status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("telegram API status %d: %s", e.StatusCode, e.Description)
+}
+
+// SendMessage sends text to a chat. parseMode may be empty for plain text.
+func (c *Client) SendMessage(chatID, text, parseMode string) error {
+	payload := map[string]string{
+		"chat_id": chatID,
+		"text":    text,
+	}
+	if parseMode != "" {
+		payload["parse_mode"] = parseMode
+	}
+	return c.callWithRetry(chatID, func() error {
+		return c.call("sendMessage", payload, nil)
+	})
+}
+
+// Update is an incoming update from getUpdates. Only messages are requested.
+type Update struct {
+	UpdateID int      `json:"update_id"`
+	Message  *Message `json:"message"`
+}
+
+// Message is an incoming chat message
+type Message struct {
+	MessageID int    `json:"message_id"`
+	Text      string `json:"text"`
+	Chat      struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+	From *struct {
+		Username string `json:"username"`
+	} `json:"from"`
+}
+
+// ChatID returns the message's chat ID in the string form used in config
+func (m *Message) ChatID() string {
+	return strconv.FormatInt(m.Chat.ID, 10)
+}
+
+// GetUpdates long-polls for updates after offset, waiting up to timeout for
+// one to arrive. It returns early with ctx's error when ctx is cancelled.
+func (c *Client) GetUpdates(ctx context.Context, offset int, timeout time.Duration) ([]Update, error) {
+	payload := map[string]interface{}{
+		"offset":          offset,
+		"timeout":         int(timeout / time.Second),
+		"allowed_updates": []string{"message"},
+	}
+	var updates []Update
+	err := c.callContext(ctx, "getUpdates", payload, &updates)
+	return updates, err
+}
+
+// callWithRetry runs send, retrying network errors, 5xx responses and 429s.
+// Every attempt waits for the rate limiter, and a 429 also holds back
+// further messages to the chat.
+func (c *Client) callWithRetry(chatID string, send func() error) error {
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		c.limiter.wait(chatID)
+		err = send()
+		if err == nil || attempt == maxAttempts {
+			break
+		}
+
+		delay := backoff(attempt)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			if !apiErr.retryable() {
+				break
+			}
+			if apiErr.RetryAfter > 0 {
+				delay = apiErr.RetryAfter
+				c.limiter.hold(chatID, delay)
+			}
+		}
+		if replay.Mode() != replay.ModeReplay {
+			time.Sleep(delay)
+		}
+	}
+	return err
+}
+
+// backoff returns the delay before retry number attempt (1-based)
+func backoff(attempt int) time.Duration {
+	delay := baseBackoff << (attempt - 1)
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
+
+// call POSTs a JSON payload to a Bot API method and decodes the result into out (if non-nil)
+func (c *Client) call(method string, payload interface{}, out interface{}) error {
+	return c.callContext(context.Background(), method, payload, out)
+}
+
+// callContext is call with a context that can cancel the request
+func (c *Client) callContext(ctx context.Context, method string, payload interface{}, out interface{}) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return c.post(ctx, method, "application/json", payloadBytes, out)
+}
+
+// post sends an encoded request body to a Bot API method and decodes the result into out (if non-nil)
+func (c *Client) post(ctx context.Context, method, contentType string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf(apiURL, c.token, method), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+		Parameters  struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil || resp.StatusCode != http.StatusOK || !result.OK {
+		return &APIError{
+			StatusCode:  resp.StatusCode,
+			Description: result.Description,
+			RetryAfter:  time.Duration(result.Parameters.RetryAfter) * time.Second,
+		}
+	}
+
+	if out != nil {
+		return json.Unmarshal(result.Result, out)
+	}
+	return nil
+}